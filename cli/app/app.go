@@ -1,17 +1,26 @@
 package app
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/signal"
 	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"golang.org/x/net/context"
 
+	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/export/kube"
+	"github.com/docker/libcompose/export/systemd"
+	"github.com/docker/libcompose/lint"
 	"github.com/docker/libcompose/project"
 	"github.com/docker/libcompose/project/options"
+	"github.com/docker/libcompose/utils"
 	"github.com/docker/libcompose/version"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -46,6 +55,9 @@ func WithProject(factory ProjectFactory, action ProjectAction) func(context *cli
 		if err != nil {
 			logrus.Fatalf("Failed to read project: %v", err)
 		}
+		if proj, ok := p.(*project.Project); ok && !context.GlobalBool("show-secrets") {
+			logrus.AddHook(proj.Sensitive.Hook())
+		}
 		return action(p, context)
 	}
 }
@@ -100,6 +112,9 @@ func ProjectDown(p project.APIProject, c *cli.Context) error {
 		RemoveImages:  options.ImageType(c.String("rmi")),
 		RemoveOrphans: c.Bool("remove-orphans"),
 	}
+	if c.Bool("dry-run") {
+		return projectPlan(p, c, project.OperationDown, options)
+	}
 	err := p.Down(context.Background(), options, c.Args()...)
 	if err != nil {
 		return cli.NewExitError(err.Error(), 1)
@@ -128,6 +143,9 @@ func ProjectCreate(p project.APIProject, c *cli.Context) error {
 		ForceRecreate: c.Bool("force-recreate"),
 		NoBuild:       c.Bool("no-build"),
 	}
+	if c.Bool("dry-run") {
+		return projectPlan(p, c, project.OperationCreate, options)
+	}
 	err := p.Create(context.Background(), options, c.Args()...)
 	if err != nil {
 		return cli.NewExitError(err.Error(), 1)
@@ -137,6 +155,10 @@ func ProjectCreate(p project.APIProject, c *cli.Context) error {
 
 // ProjectUp brings all services up.
 func ProjectUp(p project.APIProject, c *cli.Context) error {
+	attachOptions := options.Attach{
+		AbortOnContainerExit: c.Bool("abort-on-container-exit"),
+		ExitCodeFrom:         c.String("exit-code-from"),
+	}
 	options := options.Up{
 		Create: options.Create{
 			NoRecreate:    c.Bool("no-recreate"),
@@ -144,40 +166,98 @@ func ProjectUp(p project.APIProject, c *cli.Context) error {
 			NoBuild:       c.Bool("no-build"),
 			ForceBuild:    c.Bool("build"),
 		},
+		Wait:        c.Bool("wait"),
+		WaitTimeout: time.Duration(c.Int("wait-timeout")) * time.Second,
+		Rollback:    c.Bool("rollback"),
+	}
+	foreground := !c.Bool("d") && !c.Bool("wait")
+	if !foreground && (attachOptions.AbortOnContainerExit || attachOptions.ExitCodeFrom != "") {
+		return cli.NewExitError("--abort-on-container-exit and --exit-code-from can't be combined with -d or --wait", 1)
+	}
+	if c.Bool("dry-run") {
+		return projectPlan(p, c, project.OperationUp, options)
+	}
+	var proj *project.Project
+	if foreground {
+		var ok bool
+		if proj, ok = p.(*project.Project); !ok {
+			return cli.NewExitError("Attaching to the containers is not supported for this project", 1)
+		}
+	}
+
+	var exitCode int
+	interrupted, err := withSignals(p, c.Args(), func(ctx context.Context) error {
+		err := p.Up(ctx, options, c.Args()...)
+		printSummary(p)
+		if err != nil || !foreground {
+			return err
+		}
+		exitCode, err = proj.Attach(ctx, attachOptions, c.Args()...)
+		return err
+	})
+	if interrupted && err == context.Canceled {
+		// Only the attachment to the containers was interrupted
+		return nil
 	}
-	ctx, cancelFun := context.WithCancel(context.Background())
-	err := p.Up(ctx, options, c.Args()...)
 	if err != nil {
 		return cli.NewExitError(err.Error(), 1)
 	}
-	if !c.Bool("d") {
-		signalChan := make(chan os.Signal, 1)
-		cleanupDone := make(chan bool)
-		signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
-		errChan := make(chan error)
-		go func() {
-			errChan <- p.Log(ctx, true, c.Args()...)
-		}()
-		go func() {
-			select {
-			case <-signalChan:
-				fmt.Printf("\nGracefully stopping...\n")
-				cancelFun()
-				ProjectStop(p, c)
-				cleanupDone <- true
-			case err := <-errChan:
-				if err != nil {
-					logrus.Fatal(err)
-				}
-				cleanupDone <- true
-			}
-		}()
-		<-cleanupDone
-		return nil
+	if exitCode != 0 {
+		return cli.NewExitError("", exitCode)
 	}
 	return nil
 }
 
+// withSignals runs operation with a context that the first SIGINT or SIGTERM
+// cancels. Once it returns, the services are stopped in reverse dependency
+// order, each with its stop_grace_period, and a second signal kills them. It
+// returns whether a signal came, with the error of the operation, or of
+// stopping the services.
+func withSignals(p project.APIProject, services []string, operation func(ctx context.Context) error) (bool, error) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- operation(ctx)
+	}()
+	select {
+	case err := <-done:
+		return false, err
+	case <-signals:
+	}
+
+	fmt.Printf("\nGracefully stopping... (press Ctrl+C again to force)\n")
+	cancel()
+	stopCtx, cancelStop := context.WithCancel(context.Background())
+	defer cancelStop()
+	var err error
+	stopped := make(chan error, 1)
+	go func() {
+		err = <-done
+		stopped <- p.Stop(stopCtx, 0, services...)
+	}()
+	select {
+	case stopErr := <-stopped:
+		if stopErr != nil {
+			return true, fmt.Errorf("Failed to stop the services: %v", stopErr)
+		}
+	case <-signals:
+		fmt.Printf("Killing...\n")
+		killErr := p.Kill(context.Background(), "SIGKILL", services...)
+		// The containers are gone, the stop doesn't need to wait for them
+		cancelStop()
+		<-stopped
+		if killErr != nil {
+			return true, fmt.Errorf("Failed to kill the services: %v", killErr)
+		}
+	}
+	return true, err
+}
+
 // ProjectRun runs a given command within a service's container.
 func ProjectRun(p project.APIProject, c *cli.Context) error {
 	if len(c.Args()) == 0 {
@@ -239,6 +319,9 @@ func ProjectDelete(p project.APIProject, c *cli.Context) error {
 	options := options.Delete{
 		RemoveVolume: c.Bool("v"),
 	}
+	if c.Bool("dry-run") {
+		return projectPlan(p, c, project.OperationDelete, options)
+	}
 	if !c.Bool("force") {
 		stoppedContainers, err := p.Containers(context.Background(), project.Filter{
 			State: project.Stopped,
@@ -278,6 +361,54 @@ func ProjectKill(p project.APIProject, c *cli.Context) error {
 
 // ProjectConfig validates and print the compose file.
 func ProjectConfig(p project.APIProject, c *cli.Context) error {
+	if explain := c.String("explain"); explain != "" {
+		proj, ok := p.(*project.Project)
+		if !ok {
+			return cli.NewExitError("Explaining values is not supported for this project", 1)
+		}
+		parts := strings.SplitN(explain, ".", 2)
+		service, key := parts[0], ""
+		if len(parts) == 2 {
+			key = parts[1]
+		}
+		if _, ok := proj.ServiceConfigs.Get(service); !ok {
+			return cli.NewExitError(fmt.Sprintf("No such service: %s", service), 1)
+		}
+		provenance, err := proj.Provenance()
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		explanations := provenance.Explain(service, key)
+		if len(explanations) == 0 {
+			return cli.NewExitError(fmt.Sprintf("Service %s has no value for %s", service, key), 1)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, explanation := range explanations {
+			if name := strings.TrimPrefix(explanation.Key, "environment."); name != explanation.Key && !c.GlobalBool("show-secrets") && proj.Sensitive.IsSensitive(name, explanation.Value) {
+				explanation.Value = config.Mask
+			}
+			fmt.Fprintln(w, explanation)
+		}
+		return w.Flush()
+	}
+
+	if c.Bool("rendered") {
+		proj, ok := p.(*project.Project)
+		if !ok {
+			return cli.NewExitError("Rendering is not supported for this project", 1)
+		}
+		if c.Bool("quiet") {
+			return nil
+		}
+		for i, content := range proj.Rendered() {
+			if i > 0 {
+				fmt.Println("---")
+			}
+			os.Stdout.Write(content)
+		}
+		return nil
+	}
+
 	yaml, err := p.Config()
 	if err != nil {
 		return cli.NewExitError(err.Error(), 1)
@@ -288,6 +419,205 @@ func ProjectConfig(p project.APIProject, c *cli.Context) error {
 	return nil
 }
 
+// ProjectConvert converts version 1 compose files to version 2.
+func ProjectConvert(p project.APIProject, c *cli.Context) error {
+	switch format := c.String("format"); format {
+	case "docker-run":
+		return projectRenderCommands(p, c)
+	case "v2":
+	default:
+		return cli.NewExitError(fmt.Sprintf("Unknown format %s", format), 1)
+	}
+
+	converted, notes, err := p.ConvertToV2()
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	for _, note := range notes {
+		logrus.Warnf("Not converted exactly: %s", note)
+	}
+
+	if output := c.String("output"); output != "" {
+		if len(converted) != 1 {
+			return cli.NewExitError("--output can only be used with a single compose file", 1)
+		}
+		if err := ioutil.WriteFile(output, converted[0], 0644); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		return nil
+	}
+
+	for i, content := range converted {
+		if i > 0 {
+			fmt.Println("---")
+		}
+		os.Stdout.Write(content)
+	}
+	return nil
+}
+
+// printSummary prints the outcome of the last operation for each service.
+// The errors of the failed services are left to the error of the operation.
+func printSummary(p project.APIProject) {
+	proj, ok := p.(*project.Project)
+	if !ok {
+		return
+	}
+	summary := proj.Summary()
+	width := 0
+	for _, outcome := range summary {
+		if len(outcome.Service) > width {
+			width = len(outcome.Service)
+		}
+	}
+	for _, outcome := range summary {
+		line := fmt.Sprintf("%-*s  %s", width, outcome.Service, outcome.Outcome)
+		if outcome.Outcome == project.OutcomeSkipped {
+			line += ": " + outcome.Err.Error()
+		}
+		fmt.Println(line)
+	}
+}
+
+// projectPlan prints what the operation would do, one action per line.
+func projectPlan(p project.APIProject, c *cli.Context, operation project.Operation, opts interface{}) error {
+	proj, ok := p.(*project.Project)
+	if !ok {
+		return cli.NewExitError("Dry runs are not supported for this project", 1)
+	}
+	plan, err := proj.Plan(context.Background(), operation, opts, c.Args()...)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	if len(plan) == 0 {
+		fmt.Println("Nothing to do")
+	}
+	for _, action := range plan {
+		fmt.Println(action)
+	}
+	return nil
+}
+
+// projectRenderCommands prints the docker commands equivalent to creating
+// the project.
+func projectRenderCommands(p project.APIProject, c *cli.Context) error {
+	proj, ok := p.(*project.Project)
+	if !ok {
+		return cli.NewExitError("docker-run format is not supported for this project", 1)
+	}
+	commands, err := proj.RenderCommands(context.Background())
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	var buf bytes.Buffer
+	for _, command := range commands {
+		quoted := make([]string, len(command))
+		for i, arg := range command {
+			quoted[i] = utils.ShellQuote(arg)
+		}
+		fmt.Fprintln(&buf, strings.Join(quoted, " "))
+	}
+	if output := c.String("output"); output != "" {
+		if err := ioutil.WriteFile(output, buf.Bytes(), 0644); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		return nil
+	}
+	os.Stdout.Write(buf.Bytes())
+	return nil
+}
+
+// ProjectKube exports the project as Kubernetes manifests.
+func ProjectKube(p project.APIProject, c *cli.Context) error {
+	proj, ok := p.(*project.Project)
+	if !ok {
+		return cli.NewExitError("kube export is not supported for this project", 1)
+	}
+	result, err := kube.Export(proj)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	for service, unsupported := range result.Unsupported {
+		for _, u := range unsupported {
+			logrus.Warnf("Not exported exactly: %s.%s", service, u)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := result.Write(&buf); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	if output := c.String("output"); output != "" {
+		if err := ioutil.WriteFile(output, buf.Bytes(), 0644); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		return nil
+	}
+	os.Stdout.Write(buf.Bytes())
+	return nil
+}
+
+// ProjectSystemd exports the services as systemd units.
+func ProjectSystemd(p project.APIProject, c *cli.Context) error {
+	proj, ok := p.(*project.Project)
+	if !ok {
+		return cli.NewExitError("systemd export is not supported for this project", 1)
+	}
+	binary := c.String("binary")
+	if binary == "" {
+		executable, err := os.Executable()
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		binary = executable
+	}
+	result, err := systemd.Export(proj, systemd.Options{Binary: binary})
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	for service, unsupported := range result.Unsupported {
+		for _, u := range unsupported {
+			logrus.Warnf("Not exported exactly: %s.%s", service, u)
+		}
+	}
+
+	if output := c.String("output"); output != "" {
+		if err := result.WriteDir(output); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		return nil
+	}
+	if err := result.Write(os.Stdout); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	return nil
+}
+
+// ProjectLint checks services against policy rules.
+func ProjectLint(p project.APIProject, c *cli.Context) error {
+	proj, ok := p.(*project.Project)
+	if !ok {
+		return cli.NewExitError("lint is not supported for this project", 1)
+	}
+	linter := lint.NewLinter()
+	linter.Disable(c.StringSlice("disable")...)
+
+	findings := linter.Lint(proj)
+	for _, finding := range findings {
+		fmt.Println(finding)
+	}
+
+	severity := lint.Error
+	if c.Bool("strict") {
+		severity = lint.Warning
+	}
+	if lint.HasErrors(findings, severity) {
+		return cli.NewExitError("", 1)
+	}
+	return nil
+}
+
 // ProjectPause pauses service containers.
 func ProjectPause(p project.APIProject, c *cli.Context) error {
 	err := p.Pause(context.Background(), c.Args()...)
@@ -27,7 +27,17 @@ func Populate(context *project.Context, c *cli.Context) {
 		}
 	}
 
+	for _, v := range c.GlobalStringSlice("overlay") {
+		context.OverlayFiles = append(context.OverlayFiles, strings.Split(v, string(os.PathListSeparator))...)
+	}
+
+	for _, v := range c.GlobalStringSlice("values") {
+		context.ValuesFiles = append(context.ValuesFiles, strings.Split(v, string(os.PathListSeparator))...)
+	}
+
 	context.ProjectName = c.GlobalString("project-name")
+	context.ShowSecrets = c.GlobalBool("show-secrets")
+	context.Parallelism = c.GlobalInt("parallel")
 }
 
 // CreateCommand defines the libcompose create subcommand.
@@ -49,6 +59,10 @@ func CreateCommand(factory app.ProjectFactory) cli.Command {
 				Name:  "no-build",
 				Usage: "Don't build an image, even if it's missing.",
 			},
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Print the actions that would be taken, without acting",
+			},
 		},
 	}
 }
@@ -64,6 +78,69 @@ func ConfigCommand(factory app.ProjectFactory) cli.Command {
 				Name:  "quiet,q",
 				Usage: "Only validate the configuration, don't print anything.",
 			},
+			cli.BoolFlag{
+				Name:  "rendered",
+				Usage: "Print the compose files rendered with the values files, as they are parsed.",
+			},
+			cli.StringFlag{
+				Name:  "explain",
+				Usage: "Print where the values of SERVICE[.KEY] are set.",
+			},
+		},
+	}
+}
+
+// ConvertCommand defines the libcompose convert subcommand.
+func ConvertCommand(factory app.ProjectFactory) cli.Command {
+	return cli.Command{
+		Name:   "convert",
+		Usage:  "Convert version 1 compose files to version 2, or the project to docker commands.",
+		Action: app.WithProject(factory, app.ProjectConvert),
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format: v2, or docker-run for the equivalent docker commands.",
+				Value: "v2",
+			},
+			cli.StringFlag{
+				Name:  "output,o",
+				Usage: "Write the converted file to this path instead of stdout (single compose file only).",
+			},
+		},
+	}
+}
+
+// KubeCommand defines the libcompose kube subcommand.
+func KubeCommand(factory app.ProjectFactory) cli.Command {
+	return cli.Command{
+		Name:   "kube",
+		Usage:  "Export the project as Kubernetes manifests.",
+		Action: app.WithProject(factory, app.ProjectKube),
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "output,o",
+				Usage: "Write the manifests to this path instead of stdout.",
+			},
+		},
+	}
+}
+
+// LintCommand defines the libcompose lint subcommand.
+func LintCommand(factory app.ProjectFactory) cli.Command {
+	return cli.Command{
+		Name:   "lint",
+		Usage:  "Check services against policy rules.",
+		Action: app.WithProject(factory, app.ProjectLint),
+		Flags: []cli.Flag{
+			cli.StringSliceFlag{
+				Name:  "disable",
+				Usage: "Disable a rule (no-privileged, no-latest-image, memory-limit-required, no-host-network)",
+				Value: &cli.StringSlice{},
+			},
+			cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Exit with a non-zero status on warnings too.",
+			},
 		},
 	}
 }
@@ -154,6 +231,30 @@ func UpCommand(factory app.ProjectFactory) cli.Command {
 				Name:  "build",
 				Usage: "Build images before starting containers.",
 			},
+			cli.BoolFlag{
+				Name:  "rollback",
+				Usage: "Put the previous container back if its replacement fails to start or to become healthy.",
+			},
+			cli.BoolFlag{
+				Name:  "wait",
+				Usage: "Wait for the containers to be running and healthy. Implies -d.",
+			},
+			cli.IntFlag{
+				Name:  "wait-timeout",
+				Usage: "Maximum seconds to wait with --wait (default: unlimited)",
+			},
+			cli.BoolFlag{
+				Name:  "abort-on-container-exit",
+				Usage: "Stop all containers if any container exits. Incompatible with -d and --wait.",
+			},
+			cli.StringFlag{
+				Name:  "exit-code-from",
+				Usage: "Return the exit code of the container of the selected service. Implies --abort-on-container-exit.",
+			},
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Print the actions that would be taken, without acting",
+			},
 		},
 	}
 }
@@ -248,6 +349,25 @@ func StopCommand(factory app.ProjectFactory) cli.Command {
 	}
 }
 
+// SystemdCommand defines the libcompose systemd subcommand.
+func SystemdCommand(factory app.ProjectFactory) cli.Command {
+	return cli.Command{
+		Name:   "systemd",
+		Usage:  "Export the services as systemd units.",
+		Action: app.WithProject(factory, app.ProjectSystemd),
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "output,o",
+				Usage: "Write a file per unit to this directory instead of stdout.",
+			},
+			cli.StringFlag{
+				Name:  "binary",
+				Usage: "The libcompose binary the units run, the current one by default.",
+			},
+		},
+	}
+}
+
 // DownCommand defines the libcompose stop subcommand.
 func DownCommand(factory app.ProjectFactory) cli.Command {
 	return cli.Command{
@@ -267,6 +387,10 @@ func DownCommand(factory app.ProjectFactory) cli.Command {
 				Name:  "remove-orphans",
 				Usage: "Remove containers for services not defined in the Compose file",
 			},
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Print the actions that would be taken, without acting",
+			},
 		},
 	}
 }
@@ -301,6 +425,10 @@ func RmCommand(factory app.ProjectFactory) cli.Command {
 				Name:  "v",
 				Usage: "Remove volumes associated with containers",
 			},
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Print the actions that would be taken, without acting",
+			},
 		},
 	}
 }
@@ -388,5 +516,23 @@ func CommonFlags() []cli.Flag {
 			Usage:  "Specify an alternate project name (default: directory name)",
 			EnvVar: "COMPOSE_PROJECT_NAME",
 		},
+		cli.StringSliceFlag{
+			Name:  "overlay",
+			Usage: "Specify one or more JSON Patch or strategic merge files applied to the merged compose files",
+			Value: &cli.StringSlice{},
+		},
+		cli.StringSliceFlag{
+			Name:  "values",
+			Usage: "Specify one or more values files to render the compose files as templates",
+			Value: &cli.StringSlice{},
+		},
+		cli.BoolFlag{
+			Name:  "show-secrets",
+			Usage: "Don't mask sensitive values in the configuration, events and logs",
+		},
+		cli.IntFlag{
+			Name:  "parallel",
+			Usage: "Maximum number of services, and of containers of a service, acted on at once (default: unlimited)",
+		},
 	}
 }
@@ -1,7 +1,15 @@
 package app
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/net/context"
+
 	"github.com/docker/libcompose/cli/command"
+	"github.com/docker/libcompose/docker"
 	"github.com/docker/libcompose/docker/client"
 	"github.com/docker/libcompose/docker/ctx"
 	"github.com/sirupsen/logrus"
@@ -46,17 +54,80 @@ func Populate(context *ctx.Context, c *cli.Context) {
 
 	context.ConfigDir = c.String("configdir")
 
+	clientFactory, err := client.NewDefaultFactory(clientOptions(c))
+	if err != nil {
+		logrus.Fatalf("Failed to construct Docker client: %v", err)
+	}
+
+	context.ClientFactory = clientFactory
+}
+
+func clientOptions(c *cli.Context) client.Options {
 	opts := client.Options{}
 	opts.TLS = c.GlobalBool("tls")
 	opts.TLSVerify = c.GlobalBool("tlsverify")
 	opts.TLSOptions.CAFile = c.GlobalString("tlscacert")
 	opts.TLSOptions.CertFile = c.GlobalString("tlscert")
 	opts.TLSOptions.KeyFile = c.GlobalString("tlskey")
+	return opts
+}
 
-	clientFactory, err := client.NewDefaultFactory(opts)
+// ImportCommand defines the import subcommand, which doesn't need a compose
+// file.
+func ImportCommand() cli.Command {
+	return cli.Command{
+		Name:      "import",
+		Usage:     "Generate a compose file from existing containers.",
+		ArgsUsage: "[CONTAINER...]",
+		Action:    Import,
+		Flags: []cli.Flag{
+			cli.StringSliceFlag{
+				Name:  "filter,f",
+				Usage: "Import the containers with this label, like com.example.app=web",
+				Value: &cli.StringSlice{},
+			},
+			cli.StringFlag{
+				Name:  "output,o",
+				Usage: "Write the compose file to this path instead of stdout.",
+			},
+		},
+	}
+}
+
+// Import generates a compose file from existing containers.
+func Import(c *cli.Context) error {
+	options := docker.ImportOptions{
+		Containers: c.Args(),
+		Labels:     c.StringSlice("filter"),
+	}
+	for _, label := range options.Labels {
+		if strings.HasPrefix(label, "label=") {
+			return cli.NewExitError(fmt.Sprintf("Invalid filter %s, only labels are supported, without the label= prefix", label), 1)
+		}
+	}
+	if len(options.Containers) == 0 && len(options.Labels) == 0 {
+		return cli.NewExitError("Specify containers or a label filter to import", 1)
+	}
+
+	apiClient, err := client.Create(clientOptions(c))
 	if err != nil {
-		logrus.Fatalf("Failed to construct Docker client: %v", err)
+		return cli.NewExitError(err.Error(), 1)
+	}
+	builder, err := docker.Import(context.Background(), apiClient, options)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+	content, err := builder.Marshal()
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
 	}
 
-	context.ClientFactory = clientFactory
+	if output := c.String("output"); output != "" {
+		if err := ioutil.WriteFile(output, content, 0644); err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+		return nil
+	}
+	os.Stdout.Write(content)
+	return nil
 }
@@ -52,10 +52,14 @@ Options:
 	app.Commands = []cli.Command{
 		command.BuildCommand(factory),
 		command.ConfigCommand(factory),
+		command.ConvertCommand(factory),
 		command.CreateCommand(factory),
 		command.EventsCommand(factory),
+		dockerApp.ImportCommand(),
 		command.DownCommand(factory),
 		command.KillCommand(factory),
+		command.KubeCommand(factory),
+		command.LintCommand(factory),
 		command.LogsCommand(factory),
 		command.PauseCommand(factory),
 		command.PortCommand(factory),
@@ -67,6 +71,7 @@ Options:
 		command.ScaleCommand(factory),
 		command.StartCommand(factory),
 		command.StopCommand(factory),
+		command.SystemdCommand(factory),
 		command.UnpauseCommand(factory),
 		command.UpCommand(factory),
 		command.VersionCommand(factory),
@@ -0,0 +1,406 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/libcompose/utils"
+	composeYaml "github.com/docker/libcompose/yaml"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Builder builds the services, volumes and networks of a version 2 compose
+// file programmatically, for example:
+//
+//	builder := config.NewBuilder()
+//	builder.Service("web").Image("nginx").Port("80:80").Network("front")
+//	builder.Network("front").Driver("bridge")
+//	bytes, err := builder.Marshal()
+//
+// Build validates the result against the same schema as compose files.
+type Builder struct {
+	services map[string]*ServiceBuilder
+	volumes  map[string]*VolumeBuilder
+	networks map[string]*NetworkBuilder
+}
+
+// NewBuilder creates an empty Builder.
+func NewBuilder() *Builder {
+	return &Builder{
+		services: map[string]*ServiceBuilder{},
+		volumes:  map[string]*VolumeBuilder{},
+		networks: map[string]*NetworkBuilder{},
+	}
+}
+
+// Service returns the builder of the specified service, adding it if needed.
+func (b *Builder) Service(name string) *ServiceBuilder {
+	service, ok := b.services[name]
+	if !ok {
+		service = &ServiceBuilder{config: &ServiceConfig{}}
+		b.services[name] = service
+	}
+	return service
+}
+
+// Volume returns the builder of the specified volume, adding it if needed.
+func (b *Builder) Volume(name string) *VolumeBuilder {
+	volume, ok := b.volumes[name]
+	if !ok {
+		volume = &VolumeBuilder{config: &VolumeConfig{}}
+		b.volumes[name] = volume
+	}
+	return volume
+}
+
+// Network returns the builder of the specified network, adding it if needed.
+func (b *Builder) Network(name string) *NetworkBuilder {
+	network, ok := b.networks[name]
+	if !ok {
+		network = &NetworkBuilder{config: &NetworkConfig{}}
+		b.networks[name] = network
+	}
+	return network
+}
+
+// Build validates and returns the configurations built so far. Services are
+// validated against the version 2 schema, and the networks, volumes and
+// services they refer to must be defined.
+func (b *Builder) Build() (map[string]*ServiceConfig, map[string]*VolumeConfig, map[string]*NetworkConfig, error) {
+	services := map[string]*ServiceConfig{}
+	rawServices := RawServiceMap{}
+	for name, service := range b.services {
+		services[name] = service.config
+		var rawService RawService
+		if err := utils.Convert(service.config, &rawService); err != nil {
+			return nil, nil, nil, err
+		}
+		rawServices[name] = rawService
+	}
+	volumes := map[string]*VolumeConfig{}
+	for name, volume := range b.volumes {
+		volumes[name] = volume.config
+	}
+	networks := map[string]*NetworkConfig{}
+	for name, network := range b.networks {
+		networks[name] = network.config
+	}
+
+	if err := validateV2(rawServices); err != nil {
+		return nil, nil, nil, err
+	}
+	var validationErrors []string
+	for _, name := range sortedKeys(rawServices) {
+		if err := validateServiceConstraintsv2(rawServices[name], name); err != nil {
+			validationErrors = append(validationErrors, err.Error())
+		}
+		validationErrors = append(validationErrors, validateReferences(name, services[name], services, volumes, networks)...)
+	}
+	if len(validationErrors) > 0 {
+		return nil, nil, nil, fmt.Errorf(strings.Join(validationErrors, "\n"))
+	}
+
+	return services, volumes, networks, nil
+}
+
+// Marshal builds the configurations and serializes them with MarshalV2.
+func (b *Builder) Marshal() ([]byte, error) {
+	services, volumes, networks, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return MarshalV2(services, volumes, networks)
+}
+
+// validateReferences checks that a service only refers to defined
+// services, named volumes and networks.
+func validateReferences(name string, service *ServiceConfig, services map[string]*ServiceConfig, volumes map[string]*VolumeConfig, networks map[string]*NetworkConfig) []string {
+	var validationErrors []string
+	for _, dependency := range service.DependsOn {
+		if _, ok := services[dependency]; !ok {
+			validationErrors = append(validationErrors, fmt.Sprintf("Service '%s' depends on undefined service '%s'", name, dependency))
+		}
+	}
+	for _, link := range service.Links {
+		link = strings.SplitN(link, ":", 2)[0]
+		if _, ok := services[link]; !ok {
+			validationErrors = append(validationErrors, fmt.Sprintf("Service '%s' has a link to undefined service '%s'", name, link))
+		}
+	}
+	if service.Networks != nil {
+		for _, network := range service.Networks.Networks {
+			if _, ok := networks[network.Name]; !ok && network.Name != "default" {
+				validationErrors = append(validationErrors, fmt.Sprintf("Service '%s' uses an undefined network '%s'", name, network.Name))
+			}
+		}
+	}
+	if service.Volumes != nil {
+		for _, volume := range service.Volumes.Volumes {
+			if volume.Source == "" || !IsNamedVolume(volume.Source) {
+				continue
+			}
+			if _, ok := volumes[volume.Source]; !ok {
+				validationErrors = append(validationErrors, fmt.Sprintf("Service '%s' uses an undefined volume '%s'", name, volume.Source))
+			}
+		}
+	}
+	return validationErrors
+}
+
+func sortedKeys(services RawServiceMap) []string {
+	keys := make([]string, 0, len(services))
+	for key := range services {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// v2File is the layout of a version 2 compose file.
+type v2File struct {
+	Version  string                    `yaml:"version"`
+	Services map[string]*ServiceConfig `yaml:"services,omitempty"`
+	Volumes  map[string]*VolumeConfig  `yaml:"volumes,omitempty"`
+	Networks map[string]*NetworkConfig `yaml:"networks,omitempty"`
+}
+
+// MarshalV2 serializes services, volumes and networks as a complete
+// `version: "2"` compose file.
+func MarshalV2(services map[string]*ServiceConfig, volumes map[string]*VolumeConfig, networks map[string]*NetworkConfig) ([]byte, error) {
+	return yaml.Marshal(v2File{
+		Version:  "2",
+		Services: services,
+		Volumes:  volumes,
+		Networks: networks,
+	})
+}
+
+// ServiceBuilder builds the configuration of a service, see Builder.
+type ServiceBuilder struct {
+	config *ServiceConfig
+}
+
+// Config returns the configuration built so far.
+func (s *ServiceBuilder) Config() *ServiceConfig {
+	return s.config
+}
+
+// Configure calls fn to set the fields that have no builder method.
+func (s *ServiceBuilder) Configure(fn func(config *ServiceConfig)) *ServiceBuilder {
+	fn(s.config)
+	return s
+}
+
+// Image sets the image of the service.
+func (s *ServiceBuilder) Image(image string) *ServiceBuilder {
+	s.config.Image = image
+	return s
+}
+
+// Build sets the build context of the service.
+func (s *ServiceBuilder) Build(context string) *ServiceBuilder {
+	s.config.Build.Context = context
+	return s
+}
+
+// Dockerfile sets the Dockerfile of the build, relative to its context.
+func (s *ServiceBuilder) Dockerfile(dockerfile string) *ServiceBuilder {
+	s.config.Build.Dockerfile = dockerfile
+	return s
+}
+
+// BuildArg sets a build argument.
+func (s *ServiceBuilder) BuildArg(key, value string) *ServiceBuilder {
+	if s.config.Build.Args == nil {
+		s.config.Build.Args = map[string]*string{}
+	}
+	s.config.Build.Args[key] = &value
+	return s
+}
+
+// Command sets the command of the service.
+func (s *ServiceBuilder) Command(command ...string) *ServiceBuilder {
+	s.config.Command = composeYaml.Command(command)
+	return s
+}
+
+// Entrypoint sets the entrypoint of the service.
+func (s *ServiceBuilder) Entrypoint(entrypoint ...string) *ServiceBuilder {
+	s.config.Entrypoint = composeYaml.Command(entrypoint)
+	return s
+}
+
+// Env sets an environment variable, replacing its previous value.
+func (s *ServiceBuilder) Env(key, value string) *ServiceBuilder {
+	variable := key + "=" + value
+	for i, item := range s.config.Environment {
+		if item == key || strings.HasPrefix(item, key+"=") {
+			s.config.Environment[i] = variable
+			return s
+		}
+	}
+	s.config.Environment = append(s.config.Environment, variable)
+	return s
+}
+
+// Label sets a label.
+func (s *ServiceBuilder) Label(key, value string) *ServiceBuilder {
+	if s.config.Labels == nil {
+		s.config.Labels = composeYaml.SliceorMap{}
+	}
+	s.config.Labels[key] = value
+	return s
+}
+
+// Port publishes ports, like "8080:80".
+func (s *ServiceBuilder) Port(ports ...string) *ServiceBuilder {
+	s.config.Ports = append(s.config.Ports, ports...)
+	return s
+}
+
+// Expose exposes ports to the linked services.
+func (s *ServiceBuilder) Expose(ports ...string) *ServiceBuilder {
+	s.config.Expose = append(s.config.Expose, ports...)
+	return s
+}
+
+// Volume mounts a volume, like "data:/data:ro". The source is either a path
+// or the name of a volume defined with Builder.Volume.
+func (s *ServiceBuilder) Volume(volume string) *ServiceBuilder {
+	if s.config.Volumes == nil {
+		s.config.Volumes = &composeYaml.Volumes{}
+	}
+	parts := strings.SplitN(volume, ":", 3)
+	v := &composeYaml.Volume{Destination: parts[0]}
+	if len(parts) > 1 {
+		v.Source, v.Destination = parts[0], parts[1]
+	}
+	if len(parts) > 2 {
+		v.AccessMode = parts[2]
+	}
+	s.config.Volumes.Volumes = append(s.config.Volumes.Volumes, v)
+	return s
+}
+
+// Network connects the service to a network defined with Builder.Network.
+func (s *ServiceBuilder) Network(name string, aliases ...string) *ServiceBuilder {
+	if s.config.Networks == nil {
+		s.config.Networks = &composeYaml.Networks{}
+	}
+	s.config.Networks.Networks = append(s.config.Networks.Networks, &composeYaml.Network{
+		Name:    name,
+		Aliases: aliases,
+	})
+	return s
+}
+
+// NetworkMode sets the network mode of the service, like "host".
+func (s *ServiceBuilder) NetworkMode(mode string) *ServiceBuilder {
+	s.config.NetworkMode = mode
+	return s
+}
+
+// DependsOn adds services to start before this one.
+func (s *ServiceBuilder) DependsOn(services ...string) *ServiceBuilder {
+	s.config.DependsOn = append(s.config.DependsOn, services...)
+	return s
+}
+
+// Link links services, like "db" or "db:database".
+func (s *ServiceBuilder) Link(links ...string) *ServiceBuilder {
+	s.config.Links = append(s.config.Links, links...)
+	return s
+}
+
+// Restart sets the restart policy of the service.
+func (s *ServiceBuilder) Restart(policy string) *ServiceBuilder {
+	s.config.Restart = policy
+	return s
+}
+
+// User sets the user of the service.
+func (s *ServiceBuilder) User(user string) *ServiceBuilder {
+	s.config.User = user
+	return s
+}
+
+// WorkingDir sets the working directory of the service.
+func (s *ServiceBuilder) WorkingDir(dir string) *ServiceBuilder {
+	s.config.WorkingDir = dir
+	return s
+}
+
+// VolumeBuilder builds the configuration of a volume, see Builder.
+type VolumeBuilder struct {
+	config *VolumeConfig
+}
+
+// Config returns the configuration built so far.
+func (v *VolumeBuilder) Config() *VolumeConfig {
+	return v.config
+}
+
+// Driver sets the driver of the volume.
+func (v *VolumeBuilder) Driver(driver string) *VolumeBuilder {
+	v.config.Driver = driver
+	return v
+}
+
+// DriverOpt sets an option of the driver.
+func (v *VolumeBuilder) DriverOpt(key, value string) *VolumeBuilder {
+	if v.config.DriverOpts == nil {
+		v.config.DriverOpts = map[string]string{}
+	}
+	v.config.DriverOpts[key] = value
+	return v
+}
+
+// External marks the volume as created outside of the project, with the
+// specified name if not empty.
+func (v *VolumeBuilder) External(name string) *VolumeBuilder {
+	v.config.External = composeYaml.External{External: true, Name: name}
+	return v
+}
+
+// NetworkBuilder builds the configuration of a network, see Builder.
+type NetworkBuilder struct {
+	config *NetworkConfig
+}
+
+// Config returns the configuration built so far.
+func (n *NetworkBuilder) Config() *NetworkConfig {
+	return n.config
+}
+
+// Driver sets the driver of the network.
+func (n *NetworkBuilder) Driver(driver string) *NetworkBuilder {
+	n.config.Driver = driver
+	return n
+}
+
+// DriverOpt sets an option of the driver.
+func (n *NetworkBuilder) DriverOpt(key, value string) *NetworkBuilder {
+	if n.config.DriverOpts == nil {
+		n.config.DriverOpts = map[string]string{}
+	}
+	n.config.DriverOpts[key] = value
+	return n
+}
+
+// External marks the network as created outside of the project, with the
+// specified name if not empty.
+func (n *NetworkBuilder) External(name string) *NetworkBuilder {
+	n.config.External = composeYaml.External{External: true, Name: name}
+	return n
+}
+
+// Subnet adds a subnet, and its gateway if not empty, to the IPAM
+// configuration of the network.
+func (n *NetworkBuilder) Subnet(subnet, gateway string) *NetworkBuilder {
+	n.config.Ipam.Config = append(n.config.Ipam.Config, IpamConfig{
+		Subnet:  subnet,
+		Gateway: gateway,
+	})
+	return n
+}
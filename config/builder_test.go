@@ -0,0 +1,135 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	builder := NewBuilder()
+	builder.Service("web").
+		Build(".").
+		BuildArg("VERSION", "1.11").
+		Command("npm", "start").
+		Env("DEBUG", "false").
+		Env("LEVEL", "info").
+		Env("DEBUG", "true").
+		Label("com.example.tier", "front").
+		Port("80:80").
+		Volume("data:/data:ro").
+		Volume("./static:/static").
+		Network("back").
+		Network("front", "www").
+		DependsOn("db").
+		Link("db:database").
+		Restart("always")
+	builder.Service("db").
+		Image("postgres").
+		Network("back").
+		Configure(func(config *ServiceConfig) {
+			config.MemLimit = 512 * 1024 * 1024
+		})
+	builder.Volume("data").Driver("local").DriverOpt("type", "tmpfs")
+	builder.Network("front").External("proxy")
+	builder.Network("back").Driver("bridge").Subnet("172.16.238.0/24", "172.16.238.1")
+
+	bytes, err := builder.Marshal()
+	assert.Nil(t, err)
+	assert.Equal(t, `version: "2"
+services:
+  db:
+    image: postgres
+    mem_limit: 512m
+    networks:
+      back: {}
+  web:
+    build:
+      args:
+        VERSION: "1.11"
+      context: .
+    command: [npm, start]
+    depends_on:
+    - db
+    environment:
+    - DEBUG=true
+    - LEVEL=info
+    labels:
+      com.example.tier: front
+    links:
+    - db:database
+    networks:
+      back: {}
+      front:
+        aliases:
+        - www
+    ports:
+    - 80:80
+    volumes:
+    - data:/data:ro
+    - ./static:/static
+    restart: always
+volumes:
+  data:
+    driver: local
+    driver_opts:
+      type: tmpfs
+networks:
+  back:
+    driver: bridge
+    ipam:
+      config:
+      - subnet: 172.16.238.0/24
+        gateway: 172.16.238.1
+  front:
+    external:
+      name: proxy
+`, string(bytes))
+
+	// The serialized file parses back to the built configurations
+	services, volumes, networks, err := builder.Build()
+	assert.Nil(t, err)
+	_, parsedServices, parsedVolumes, parsedNetworks, err := Merge(NewServiceConfigs(), nil, &NullLookup{}, "", bytes, nil)
+	assert.Nil(t, err)
+	for _, service := range parsedServices {
+		sortNetworks(service)
+	}
+	assert.Equal(t, services, parsedServices)
+	assert.Equal(t, volumes, parsedVolumes)
+	assert.Equal(t, networks, parsedNetworks)
+}
+
+func TestBuilderValidation(t *testing.T) {
+	builder := NewBuilder()
+	builder.Service("web").Port("80:80")
+	_, _, _, err := builder.Build()
+	assert.Contains(t, err.Error(), "Service 'web' has neither an image nor a build context specified")
+
+	builder = NewBuilder()
+	builder.Service("web").Image("nginx").Port("not-a-port")
+	_, _, _, err = builder.Build()
+	assert.NotNil(t, err)
+
+	builder = NewBuilder()
+	builder.Service("web").Image("nginx").Configure(func(config *ServiceConfig) {
+		config.Restart = "always"
+		config.Expose = []string{"80", "80"}
+	})
+	_, _, _, err = builder.Build()
+	assert.Contains(t, err.Error(), "non-unique elements")
+
+	builder = NewBuilder()
+	builder.Service("web").
+		Image("nginx").
+		DependsOn("db").
+		Link("cache").
+		Network("front").
+		Network("default").
+		Volume("data:/data").
+		Volume("/srv:/srv")
+	_, _, _, err = builder.Build()
+	assert.Equal(t, `Service 'web' depends on undefined service 'db'
+Service 'web' has a link to undefined service 'cache'
+Service 'web' uses an undefined network 'front'
+Service 'web' uses an undefined volume 'data'`, err.Error())
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/docker/libcompose/utils"
 	"github.com/docker/libcompose/yaml"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
 // ConvertServices converts a set of v1 service configs to v2 service configs
@@ -42,3 +46,232 @@ func ConvertServices(v1Services map[string]*ServiceConfigV1) (map[string]*Servic
 
 	return v2Services, nil
 }
+
+// ConversionNote describes something ConvertToV2 could not translate exactly.
+type ConversionNote struct {
+	File    string
+	Service string
+	Key     string
+	Message string
+}
+
+func (n ConversionNote) String() string {
+	prefix := n.Service
+	if n.Key != "" {
+		prefix += "." + n.Key
+	}
+	if n.File != "" {
+		prefix = n.File + ": " + prefix
+	}
+	return prefix + ": " + n.Message
+}
+
+// ConvertToV2 converts the content of a version 1 compose file to an
+// equivalent version 2 file. Comments and ordering are kept. `net` becomes
+// `network_mode`, `log_driver` and `log_opt` are moved into `logging` and
+// `dockerfile` into `build`. Anything that has no exact equivalent is
+// reported as a ConversionNote.
+func ConvertToV2(content []byte) ([]byte, []ConversionNote, error) {
+	if _, err := CreateConfig(content); err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := ParseDocument(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc.root == nil {
+		doc.root = &yamlv3.Node{
+			Kind:    yamlv3.DocumentNode,
+			Content: []*yamlv3.Node{{Kind: yamlv3.MappingNode, Tag: "!!map"}},
+		}
+	}
+
+	var version string
+	if _, err := doc.Get(Path{"version"}, &version); err != nil {
+		return nil, nil, err
+	}
+	if version != "" {
+		return nil, nil, fmt.Errorf("Compose file is already version %s", version)
+	}
+
+	services := doc.root.Content[0]
+
+	// Defaults and x-sensitive sit next to the services in v1 but at the
+	// top level in v2
+	var defaults, sensitive *yamlv3.Node
+	if index := mappingIndex(services, ServiceDefaultsKey); index >= 0 {
+		defaults = services.Content[index+1]
+		services.Content = append(services.Content[:index], services.Content[index+2:]...)
+	}
+	if index := mappingIndex(services, SensitiveKey); index >= 0 {
+		sensitive = services.Content[index+1]
+		services.Content = append(services.Content[:index], services.Content[index+2:]...)
+	}
+
+	serviceNames := map[string]bool{}
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		serviceNames[services.Content[i].Value] = true
+	}
+
+	notes := []ConversionNote{}
+	volumes := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name := services.Content[i].Value
+		service := services.Content[i+1]
+		if service.Kind != yamlv3.MappingNode {
+			notes = append(notes, ConversionNote{Service: name, Message: "service is not a mapping and was copied as-is"})
+			continue
+		}
+		notes = append(notes, convertServiceNodeToV2(name, service, serviceNames, volumes)...)
+	}
+	if defaults != nil && defaults.Kind == yamlv3.MappingNode {
+		notes = append(notes, convertServiceNodeToV2(ServiceDefaultsKey, defaults, serviceNames, volumes)...)
+	}
+
+	// A comment heading the first service usually heads the whole file
+	versionKey := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "version"}
+	if len(services.Content) > 0 {
+		versionKey.HeadComment = services.Content[0].HeadComment
+		services.Content[0].HeadComment = ""
+	}
+
+	root := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+	root.Content = append(root.Content,
+		versionKey,
+		&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "2", Style: yamlv3.DoubleQuotedStyle},
+		&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "services"},
+		services,
+	)
+	if defaults != nil {
+		root.Content = append(root.Content,
+			&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: ServiceDefaultsKey},
+			defaults,
+		)
+	}
+	if sensitive != nil {
+		root.Content = append(root.Content,
+			&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: SensitiveKey},
+			sensitive,
+		)
+	}
+	if len(volumes.Content) > 0 {
+		root.Content = append(root.Content,
+			&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "volumes"},
+			volumes,
+		)
+	}
+	doc.root.Content[0] = root
+
+	converted, err := doc.Bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	return converted, notes, nil
+}
+
+func convertServiceNodeToV2(name string, service *yamlv3.Node, serviceNames map[string]bool, volumes *yamlv3.Node) []ConversionNote {
+	notes := []ConversionNote{}
+
+	if i := mappingIndex(service, "net"); i >= 0 {
+		service.Content[i].Value = "network_mode"
+	}
+
+	logDriver := mappingIndex(service, "log_driver")
+	logOpt := mappingIndex(service, "log_opt")
+	if logDriver >= 0 || logOpt >= 0 {
+		logging := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		position := logDriver
+		if logDriver >= 0 {
+			logging.Content = append(logging.Content, scalarKey("driver", service.Content[logDriver]), service.Content[logDriver+1])
+		}
+		if logOpt >= 0 {
+			logging.Content = append(logging.Content, scalarKey("options", service.Content[logOpt]), service.Content[logOpt+1])
+			if position < 0 || logOpt < position {
+				position = logOpt
+			}
+		}
+		service.Content[position] = scalarKey("logging", service.Content[position])
+		service.Content[position+1] = logging
+		removeMappingKey(service, "log_driver")
+		removeMappingKey(service, "log_opt")
+	}
+
+	if i := mappingIndex(service, "dockerfile"); i >= 0 {
+		dockerfile := service.Content[i+1]
+		if build := mappingValue(service, "build"); build != nil {
+			context := *build
+			build.Kind = yamlv3.MappingNode
+			build.Tag = "!!map"
+			build.Style = 0
+			build.Value = ""
+			build.HeadComment, build.LineComment, build.FootComment = "", "", ""
+			build.Content = []*yamlv3.Node{
+				{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "context"}, &context,
+				{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "dockerfile"}, dockerfile,
+			}
+		} else {
+			notes = append(notes, ConversionNote{Service: name, Key: "dockerfile", Message: "dockerfile without build has no version 2 equivalent and was removed"})
+		}
+		removeMappingKey(service, "dockerfile")
+	}
+
+	if volumesFrom := mappingValue(service, "volumes_from"); volumesFrom != nil && volumesFrom.Kind == yamlv3.SequenceNode {
+		for _, item := range volumesFrom.Content {
+			source := strings.SplitN(item.Value, ":", 2)[0]
+			if item.Kind != yamlv3.ScalarNode || serviceNames[source] || strings.HasPrefix(item.Value, "container:") {
+				continue
+			}
+			item.Value = "container:" + item.Value
+			notes = append(notes, ConversionNote{Service: name, Key: "volumes_from", Message: fmt.Sprintf("%s is not a service of this file, it is assumed to be a container", source)})
+		}
+	}
+
+	if serviceVolumes := mappingValue(service, "volumes"); serviceVolumes != nil && serviceVolumes.Kind == yamlv3.SequenceNode {
+		for _, item := range serviceVolumes.Content {
+			parts := strings.SplitN(item.Value, ":", 2)
+			if item.Kind != yamlv3.ScalarNode || len(parts) < 2 || parts[0] == "" || !IsNamedVolume(parts[0]) || mappingValue(volumes, parts[0]) != nil {
+				continue
+			}
+			volumes.Content = append(volumes.Content,
+				&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: parts[0]},
+				&yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map", Content: []*yamlv3.Node{
+					{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "external"},
+					{Kind: yamlv3.ScalarNode, Tag: "!!bool", Value: "true"},
+				}},
+			)
+			notes = append(notes, ConversionNote{Service: name, Key: "volumes", Message: fmt.Sprintf("named volume %s is declared external, it must exist before the project is started", parts[0])})
+		}
+	}
+
+	if extends := mappingValue(service, "extends"); extends != nil {
+		if file := mappingValue(extends, "file"); file != nil {
+			notes = append(notes, ConversionNote{Service: name, Key: "extends", Message: fmt.Sprintf("%s must be converted to version 2 as well", file.Value)})
+		}
+	}
+
+	for i := 0; i+1 < len(service.Content); i += 2 {
+		if service.Content[i].Tag == "!!merge" {
+			notes = append(notes, ConversionNote{Service: name, Key: "<<", Message: "keys inherited through a merge key were not converted"})
+		}
+	}
+
+	return notes
+}
+
+func scalarKey(value string, from *yamlv3.Node) *yamlv3.Node {
+	return &yamlv3.Node{
+		Kind:        yamlv3.ScalarNode,
+		Tag:         "!!str",
+		Value:       value,
+		HeadComment: from.HeadComment,
+		LineComment: from.LineComment,
+		FootComment: from.FootComment,
+	}
+}
+
+func removeMappingKey(node *yamlv3.Node, key string) {
+	if i := mappingIndex(node, key); i >= 0 {
+		node.Content = append(node.Content[:i], node.Content[i+2:]...)
+	}
+}
@@ -73,3 +73,98 @@ func TestNetworkMode(t *testing.T) {
 		t.Fatal("Failed to convert network mode", v2Config.NetworkMode)
 	}
 }
+
+func TestConvertToV2(t *testing.T) {
+	converted, notes, err := ConvertToV2([]byte(`# Front services
+web:
+  build: . # local build
+  dockerfile: Dockerfile.web
+  net: host
+  log_driver: syslog
+  log_opt:
+    tag: web
+  volumes:
+    - data:/data
+    - ./conf:/etc/web
+  volumes_from:
+    - db
+    - storage:ro
+db:
+  image: postgres
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# Front services
+version: "2"
+services:
+  web:
+    build:
+      context: . # local build
+      dockerfile: Dockerfile.web
+    network_mode: host
+    logging:
+      driver: syslog
+      options:
+        tag: web
+    volumes:
+      - data:/data
+      - ./conf:/etc/web
+    volumes_from:
+      - db
+      - container:storage:ro
+  db:
+    image: postgres
+volumes:
+  data:
+    external: true
+`
+	if string(converted) != expected {
+		t.Fatalf("Unexpected conversion, got\n%s", converted)
+	}
+
+	if len(notes) != 2 {
+		t.Fatal("Expected two conversion notes, got", notes)
+	}
+	if notes[0].Key != "volumes_from" || notes[1].Key != "volumes" {
+		t.Fatal("Unexpected conversion notes", notes)
+	}
+
+	var config *Config
+	if config, err = CreateConfig(converted); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateV2(config.Services); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConvertToV2AlreadyV2(t *testing.T) {
+	if _, _, err := ConvertToV2([]byte("version: \"2\"\nservices: {}\n")); err == nil {
+		t.Fatal("Expected an error converting a version 2 file")
+	}
+}
+
+func TestConvertToV2ServiceDefaults(t *testing.T) {
+	converted, _, err := ConvertToV2([]byte(`x-service-defaults:
+  log_driver: syslog
+web:
+  image: nginx
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `version: "2"
+services:
+  web:
+    image: nginx
+x-service-defaults:
+  logging:
+    driver: syslog
+`
+	if string(converted) != expected {
+		t.Fatalf("Unexpected conversion, got:\n%s", converted)
+	}
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+)
+
+// Change is a difference between two configurations of a service.
+type Change struct {
+	// Key is the path of the changed key, like "image" or
+	// "logging.options.max-size".
+	Key string
+	// Old is the previous value, nil if the key wasn't set.
+	Old interface{}
+	// New is the current value, nil if the key isn't set anymore.
+	New interface{}
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Key, changeValue(c.Old), changeValue(c.New))
+}
+
+func changeValue(value interface{}) string {
+	if value == nil {
+		return "<unset>"
+	}
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(bytes)
+}
+
+// EncodeServiceConfig returns the keys of a service configuration that are
+// covered by GetServiceHash, compressed and encoded to fit in a label, for
+// DiffServiceConfig to compare them later.
+func EncodeServiceConfig(config *ServiceConfig) (string, error) {
+	canonical, err := canonicalService(config)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(canonical); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DiffServiceConfig compares a configuration encoded by EncodeServiceConfig
+// with the current one, and returns the changes sorted by key. Mappings are
+// compared key by key, other values as a whole.
+func DiffServiceConfig(encoded string, config *ServiceConfig) ([]Change, error) {
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid encoded configuration: %v", err)
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid encoded configuration: %v", err)
+	}
+	previous, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid encoded configuration: %v", err)
+	}
+	current, err := canonicalService(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var oldKeys, newKeys map[string]interface{}
+	if err := json.Unmarshal(previous, &oldKeys); err != nil {
+		return nil, fmt.Errorf("Invalid encoded configuration: %v", err)
+	}
+	if err := json.Unmarshal(current, &newKeys); err != nil {
+		return nil, err
+	}
+
+	changes := diffMaps("", oldKeys, newKeys)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, nil
+}
+
+func diffMaps(prefix string, oldKeys, newKeys map[string]interface{}) []Change {
+	var changes []Change
+	for key, oldValue := range oldKeys {
+		newValue, ok := newKeys[key]
+		if !ok {
+			changes = append(changes, Change{Key: prefix + key, Old: oldValue})
+			continue
+		}
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			changes = append(changes, diffMaps(prefix+key+".", oldMap, newMap)...)
+		} else if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, Change{Key: prefix + key, Old: oldValue, New: newValue})
+		}
+	}
+	for key, newValue := range newKeys {
+		if _, ok := oldKeys[key]; !ok {
+			changes = append(changes, Change{Key: prefix + key, New: newValue})
+		}
+	}
+	return changes
+}
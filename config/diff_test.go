@@ -0,0 +1,57 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffServiceConfig(t *testing.T) {
+	encoded, err := EncodeServiceConfig(parseServiceConfig(t, `
+image: nginx
+environment: [DEBUG=true]
+logging:
+  driver: json-file
+  options:
+    max-size: 10m
+privileged: true
+`))
+	assert.Nil(t, err)
+
+	changes, err := DiffServiceConfig(encoded, parseServiceConfig(t, `
+image: nginx
+environment: [DEBUG=true]
+logging:
+  driver: json-file
+  options:
+    max-size: 10m
+privileged: true
+`))
+	assert.Nil(t, err)
+	assert.Empty(t, changes)
+
+	changes, err = DiffServiceConfig(encoded, parseServiceConfig(t, `
+image: nginx:1.13
+environment: [DEBUG=false]
+logging:
+  driver: json-file
+  options:
+    max-size: 20m
+mem_limit: 512m
+`))
+	assert.Nil(t, err)
+	var lines []string
+	for _, change := range changes {
+		lines = append(lines, change.String())
+	}
+	assert.Equal(t, []string{
+		`environment.DEBUG: "true" -> "false"`,
+		`image: "nginx" -> "nginx:1.13"`,
+		`logging.options.max-size: "10m" -> "20m"`,
+		`mem_limit: <unset> -> "512m"`,
+		`privileged: true -> <unset>`,
+	}, lines)
+
+	_, err = DiffServiceConfig("not encoded", &ServiceConfig{})
+	assert.NotNil(t, err)
+}
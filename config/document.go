@@ -0,0 +1,431 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const defaultDocumentIndent = 2
+
+// Path identifies a value inside a compose document, one element per
+// mapping key or sequence index (e.g. services, web, ports, 0).
+type Path []string
+
+// ParsePath splits a dot separated path such as "services.web.image".
+// Keys that contain a dot must be given as a Path literal instead.
+func ParsePath(path string) Path {
+	if path == "" {
+		return Path{}
+	}
+	return Path(strings.Split(path, "."))
+}
+
+// String returns the dot separated form of the path.
+func (p Path) String() string {
+	return strings.Join(p, ".")
+}
+
+// Document is a compose file loaded as a YAML node tree. Contrary to going
+// through RawServiceMap, editing a Document and writing it back keeps the
+// comments, key order, quoting and anchors of the original file.
+type Document struct {
+	root   *yamlv3.Node
+	indent int
+}
+
+// ParseDocument parses the bytes of a compose file into an editable Document.
+func ParseDocument(content []byte) (*Document, error) {
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+	d := &Document{
+		indent: detectIndent(content),
+	}
+	if root.Kind == 0 {
+		return d, nil
+	}
+	if root.Kind != yamlv3.DocumentNode || len(root.Content) != 1 {
+		return nil, fmt.Errorf("Invalid compose document, expected a single YAML document")
+	}
+	if root.Content[0].Kind != yamlv3.MappingNode {
+		return nil, fmt.Errorf("Invalid compose document, top level must be a mapping")
+	}
+	d.root = &root
+	return d, nil
+}
+
+// Has returns whether the specified path exists in the document.
+func (d *Document) Has(path Path) bool {
+	_, err := d.lookup(path)
+	return err == nil
+}
+
+// Get decodes the value at the specified path into out. It returns false if
+// the path doesn't exist. Aliases and merge keys are resolved.
+func (d *Document) Get(path Path, out interface{}) (bool, error) {
+	node, err := d.lookup(path)
+	if err != nil {
+		if _, ok := err.(errPathNotFound); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := node.Decode(out); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// Position returns the line and column where the value at the specified path
+// is defined, or the position of its key for mapping entries.
+func (d *Document) Position(path Path) (int, int, bool) {
+	if d.root == nil {
+		return 0, 0, false
+	}
+	node := d.root.Content[0]
+	position := node
+	for _, key := range path {
+		node = resolveAlias(node)
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			i := mappingIndex(node, key)
+			if i < 0 {
+				return 0, 0, false
+			}
+			position, node = node.Content[i], node.Content[i+1]
+		case yamlv3.SequenceNode:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node.Content) {
+				return 0, 0, false
+			}
+			node = node.Content[index]
+			position = node
+		default:
+			return 0, 0, false
+		}
+	}
+	return position.Line, position.Column, true
+}
+
+// Set replaces (or adds) the value at the specified path, creating the
+// intermediate mappings if needed. Comments attached to a replaced value are
+// kept. An index equal to the length of a sequence appends to it.
+func (d *Document) Set(path Path, value interface{}) error {
+	if len(path) == 0 {
+		return fmt.Errorf("Cannot replace the whole document")
+	}
+	if d.root == nil {
+		d.root = &yamlv3.Node{
+			Kind:    yamlv3.DocumentNode,
+			Content: []*yamlv3.Node{{Kind: yamlv3.MappingNode, Tag: "!!map"}},
+		}
+	}
+
+	newNode, err := toNode(value)
+	if err != nil {
+		return err
+	}
+
+	parent := d.root.Content[0]
+	for i, key := range path {
+		last := i == len(path)-1
+		if parent.Kind == yamlv3.AliasNode {
+			return fmt.Errorf("Cannot edit %s: %s is an alias, editing it would change every reference to &%s", path, path[:i], parent.Value)
+		}
+
+		switch parent.Kind {
+		case yamlv3.MappingNode:
+			child := mappingValue(parent, key)
+			if child == nil {
+				if mergedValue(parent, key) != nil && !last {
+					return fmt.Errorf("Cannot edit %s: %s is inherited through a merge key", path, path[:i+1])
+				}
+				child = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+				if !last && isIndex(path[i+1]) {
+					child = &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+				}
+				parent.Content = append(parent.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key}, child)
+			}
+			if last {
+				replaceNode(child, newNode)
+				return nil
+			}
+			parent = child
+		case yamlv3.SequenceNode:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index > len(parent.Content) {
+				return fmt.Errorf("Cannot edit %s: invalid index %q for %s", path, key, path[:i])
+			}
+			if index == len(parent.Content) {
+				child := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+				if !last && isIndex(path[i+1]) {
+					child = &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+				}
+				parent.Content = append(parent.Content, child)
+			}
+			if last {
+				replaceNode(parent.Content[index], newNode)
+				return nil
+			}
+			parent = parent.Content[index]
+		default:
+			return fmt.Errorf("Cannot edit %s: %s is not a mapping or a sequence", path, path[:i])
+		}
+	}
+
+	return nil
+}
+
+// Delete removes the value at the specified path. It returns false if the
+// path didn't exist.
+func (d *Document) Delete(path Path) (bool, error) {
+	if len(path) == 0 {
+		return false, fmt.Errorf("Cannot delete the whole document")
+	}
+	if d.root == nil {
+		return false, nil
+	}
+
+	parent := d.root.Content[0]
+	for i, key := range path {
+		last := i == len(path)-1
+		if parent.Kind == yamlv3.AliasNode {
+			return false, fmt.Errorf("Cannot edit %s: %s is an alias, editing it would change every reference to &%s", path, path[:i], parent.Value)
+		}
+
+		switch parent.Kind {
+		case yamlv3.MappingNode:
+			index := mappingIndex(parent, key)
+			if index < 0 {
+				if mergedValue(parent, key) != nil {
+					return false, fmt.Errorf("Cannot edit %s: %s is inherited through a merge key", path, path[:i+1])
+				}
+				return false, nil
+			}
+			if last {
+				parent.Content = append(parent.Content[:index], parent.Content[index+2:]...)
+				return true, nil
+			}
+			parent = parent.Content[index+1]
+		case yamlv3.SequenceNode:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 {
+				return false, fmt.Errorf("Cannot edit %s: invalid index %q for %s", path, key, path[:i])
+			}
+			if index >= len(parent.Content) {
+				return false, nil
+			}
+			if last {
+				parent.Content = append(parent.Content[:index], parent.Content[index+1:]...)
+				return true, nil
+			}
+			parent = parent.Content[index]
+		default:
+			return false, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Bytes returns the YAML representation of the document.
+func (d *Document) Bytes() ([]byte, error) {
+	if d.root == nil {
+		return []byte{}, nil
+	}
+	// The encoder writes merge keys as `!!merge <<` when they carry their
+	// resolved tag, so drop it while encoding.
+	mergeKeys := collectMergeKeys(d.root, nil)
+	for _, key := range mergeKeys {
+		key.Tag = ""
+	}
+	defer func() {
+		for _, key := range mergeKeys {
+			key.Tag = "!!merge"
+		}
+	}()
+
+	var buf bytes.Buffer
+	encoder := yamlv3.NewEncoder(&buf)
+	encoder.SetIndent(d.indent)
+	if err := encoder.Encode(d.root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Config parses the current state of the document into a Config.
+func (d *Document) Config() (*Config, error) {
+	content, err := d.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return CreateConfig(content)
+}
+
+type errPathNotFound Path
+
+func (e errPathNotFound) Error() string {
+	return fmt.Sprintf("%s not found", Path(e))
+}
+
+func (d *Document) lookup(path Path) (*yamlv3.Node, error) {
+	if d.root == nil {
+		return nil, errPathNotFound(path)
+	}
+	node := d.root.Content[0]
+	for i, key := range path {
+		node = resolveAlias(node)
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			child := mappingValue(node, key)
+			if child == nil {
+				child = mergedValue(node, key)
+			}
+			if child == nil {
+				return nil, errPathNotFound(path)
+			}
+			node = child
+		case yamlv3.SequenceNode:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("Invalid index %q for %s", key, path[:i])
+			}
+			if index >= len(node.Content) {
+				return nil, errPathNotFound(path)
+			}
+			node = node.Content[index]
+		default:
+			return nil, errPathNotFound(path)
+		}
+	}
+	return resolveAlias(node), nil
+}
+
+func resolveAlias(node *yamlv3.Node) *yamlv3.Node {
+	for node.Kind == yamlv3.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+func mappingIndex(node *yamlv3.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key && node.Content[i].Tag != "!!merge" {
+			return i
+		}
+	}
+	return -1
+}
+
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if i := mappingIndex(node, key); i >= 0 {
+		return node.Content[i+1]
+	}
+	return nil
+}
+
+// mergedValue looks up key in the mappings merged into node with `<<`.
+func mergedValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Tag != "!!merge" {
+			continue
+		}
+		merged := resolveAlias(node.Content[i+1])
+		sources := []*yamlv3.Node{merged}
+		if merged.Kind == yamlv3.SequenceNode {
+			sources = merged.Content
+		}
+		for _, source := range sources {
+			source = resolveAlias(source)
+			if source.Kind != yamlv3.MappingNode {
+				continue
+			}
+			if value := mappingValue(source, key); value != nil {
+				return value
+			}
+			if value := mergedValue(source, key); value != nil {
+				return value
+			}
+		}
+	}
+	return nil
+}
+
+func collectMergeKeys(node *yamlv3.Node, keys []*yamlv3.Node) []*yamlv3.Node {
+	if node.Kind == yamlv3.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Tag == "!!merge" {
+				keys = append(keys, node.Content[i])
+			}
+		}
+	}
+	for _, child := range node.Content {
+		keys = collectMergeKeys(child, keys)
+	}
+	return keys
+}
+
+func toNode(value interface{}) (*yamlv3.Node, error) {
+	switch v := value.(type) {
+	case *yamlv3.Node:
+		return v, nil
+	case yamlv3.Node:
+		return &v, nil
+	}
+	var node yamlv3.Node
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// replaceNode swaps the content of target with value, keeping the comments
+// of target and the quoting style of a replaced string.
+func replaceNode(target, value *yamlv3.Node) {
+	replaced := *value
+	replaced.HeadComment = target.HeadComment
+	replaced.LineComment = target.LineComment
+	replaced.FootComment = target.FootComment
+	if target.Kind == yamlv3.ScalarNode && replaced.Kind == yamlv3.ScalarNode && target.Tag == replaced.Tag && replaced.Style == 0 {
+		replaced.Style = target.Style
+	}
+	if replaced.Kind != yamlv3.ScalarNode {
+		replaced.Style = replaced.Style &^ yamlv3.FlowStyle
+		if target.Kind == replaced.Kind {
+			replaced.Style |= target.Style & yamlv3.FlowStyle
+		}
+	}
+	*target = replaced
+}
+
+func isIndex(key string) bool {
+	_, err := strconv.Atoi(key)
+	return err == nil
+}
+
+// detectIndent returns the indentation width used by the first nested line
+// of content, so that written back documents keep the same layout.
+func detectIndent(content []byte) int {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "- ") {
+			continue
+		}
+		if indent := len(line) - len(trimmed); indent > 1 {
+			return indent
+		}
+	}
+	return defaultDocumentIndent
+}
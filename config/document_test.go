@@ -0,0 +1,144 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var documentSample = `# Front services
+version: "2"
+services:
+  web:
+    image: "nginx:1.10" # pinned
+    environment:
+      DEBUG: "false"
+    ports:
+      - "80:80"
+      - "443:443"
+  db: &db
+    image: postgres
+    environment:
+      USER: app
+  db2:
+    <<: *db
+    container_name: db2
+`
+
+func TestDocumentGet(t *testing.T) {
+	doc, err := ParseDocument([]byte(documentSample))
+	assert.Nil(t, err)
+
+	var image string
+	found, err := doc.Get(ParsePath("services.web.image"), &image)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "nginx:1.10", image)
+
+	var port string
+	found, err = doc.Get(ParsePath("services.web.ports.1"), &port)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "443:443", port)
+
+	found, err = doc.Get(ParsePath("services.db2.image"), &image)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "postgres", image)
+
+	found, err = doc.Get(ParsePath("services.cache"), &image)
+	assert.Nil(t, err)
+	assert.False(t, found)
+}
+
+func TestDocumentSetAndDelete(t *testing.T) {
+	doc, err := ParseDocument([]byte(documentSample))
+	assert.Nil(t, err)
+
+	assert.Nil(t, doc.Set(ParsePath("services.web.image"), "nginx:1.11"))
+	assert.Nil(t, doc.Set(ParsePath("services.web.environment.LEVEL"), "info"))
+	assert.Nil(t, doc.Set(ParsePath("services.db2.image"), "postgres:9.6"))
+	assert.Nil(t, doc.Set(ParsePath("services.cache.image"), "redis"))
+
+	deleted, err := doc.Delete(ParsePath("services.web.ports.0"))
+	assert.Nil(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = doc.Delete(ParsePath("services.web.links"))
+	assert.Nil(t, err)
+	assert.False(t, deleted)
+
+	bytes, err := doc.Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, `# Front services
+version: "2"
+services:
+  web:
+    image: "nginx:1.11" # pinned
+    environment:
+      DEBUG: "false"
+      LEVEL: info
+    ports:
+      - "443:443"
+  db: &db
+    image: postgres
+    environment:
+      USER: app
+  db2:
+    <<: *db
+    container_name: db2
+    image: postgres:9.6
+  cache:
+    image: redis
+`, string(bytes))
+
+	config, err := doc.Config()
+	assert.Nil(t, err)
+	assert.Equal(t, "postgres:9.6", config.Services["db2"]["image"])
+}
+
+func TestDocumentRefusesSharedEdits(t *testing.T) {
+	doc, err := ParseDocument([]byte(documentSample))
+	assert.Nil(t, err)
+
+	err = doc.Set(ParsePath("services.db2.environment.USER"), "other")
+	assert.NotNil(t, err)
+
+	err = doc.Set(ParsePath("services.web.image.tag"), "latest")
+	assert.NotNil(t, err)
+}
+
+func TestDocumentEmpty(t *testing.T) {
+	doc, err := ParseDocument([]byte{})
+	assert.Nil(t, err)
+
+	assert.Nil(t, doc.Set(Path{"version"}, "2"))
+	assert.Nil(t, doc.Set(ParsePath("services.web.ports.0"), "8080:80"))
+
+	bytes, err := doc.Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, `version: "2"
+services:
+  web:
+    ports:
+      - 8080:80
+`, string(bytes))
+}
+
+func TestDocumentPosition(t *testing.T) {
+	doc, err := ParseDocument([]byte(documentSample))
+	assert.Nil(t, err)
+
+	line, column, found := doc.Position(ParsePath("services.web.image"))
+	assert.True(t, found)
+	assert.Equal(t, 5, line)
+	assert.Equal(t, 5, column)
+
+	line, column, found = doc.Position(ParsePath("services.web.ports.1"))
+	assert.True(t, found)
+	assert.Equal(t, 10, line)
+	assert.Equal(t, 9, column)
+
+	_, _, found = doc.Position(ParsePath("services.web.links"))
+	assert.False(t, found)
+}
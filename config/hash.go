@@ -2,19 +2,221 @@ package config
 
 import (
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/docker/libcompose/yaml"
+	"github.com/sirupsen/logrus"
+	yamlv2 "gopkg.in/yaml.v2"
 )
 
+// ServiceHashVersion is the version of the scheme used by GetServiceHash.
+const ServiceHashVersion = 2
+
+// unorderedKeys are the service keys whose items order doesn't matter, and
+// which are sorted before hashing.
+var unorderedKeys = map[string]bool{
+	"cap_add":        true,
+	"cap_drop":       true,
+	"depends_on":     true,
+	"devices":        true,
+	"dns_opt":        true,
+	"expose":         true,
+	"external_links": true,
+	"extra_hosts":    true,
+	"group_add":      true,
+	"links":          true,
+	"ports":          true,
+	"security_opt":   true,
+	"tmpfs":          true,
+	"volumes":        true,
+	"volumes_from":   true,
+}
+
+// unhashedKeys are the service keys that don't shape the containers, and
+// which changing doesn't recreate them.
+var unhashedKeys = map[string]bool{
+	"x-update-config": true,
+}
+
+// keyedKeys are the service keys whose lists of KEY=VALUE are hashed as
+// mappings, the last value of a key winning.
+var keyedKeys = map[string]bool{
+	"environment": true,
+}
+
 // GetServiceHash computes and returns a hash that will identify a service.
 // This hash will be then used to detect if the service definition/configuration
 // have changed and needs to be recreated.
+//
+// The hash only covers the keys that are set, with their canonical yaml
+// values, so that adding fields to ServiceConfig doesn't change it. It is
+// prefixed with the version of the scheme, like "v2:".
 func GetServiceHash(name string, config *ServiceConfig) string {
+	hash := sha256.New()
+	io.WriteString(hash, name)
+	io.WriteString(hash, "\n")
+
+	canonical, err := canonicalService(config)
+	if err != nil {
+		logrus.Warnf("Failed to compute the configuration hash of %s: %v", name, err)
+	}
+	hash.Write(canonical)
+
+	return fmt.Sprintf("v%d:%s", ServiceHashVersion, hex.EncodeToString(hash.Sum(nil)))
+}
+
+// ServiceHashMatches checks if a hash, as computed by GetServiceHash by this
+// or a previous version of libcompose, matches the service configuration.
+// Hashes without version are from the first scheme, GetLegacyServiceHash.
+func ServiceHashMatches(name string, config *ServiceConfig, hash string) bool {
+	version, _ := ParseServiceHash(hash)
+	switch version {
+	case 1:
+		return hash == GetLegacyServiceHash(name, config)
+	case ServiceHashVersion:
+		return hash == GetServiceHash(name, config)
+	}
+	return false
+}
+
+// ParseServiceHash returns the scheme version and the digest of a hash, or
+// a zero version if the hash is malformed.
+func ParseServiceHash(hash string) (int, string) {
+	if !strings.HasPrefix(hash, "v") {
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha1.Size*2 {
+			return 0, ""
+		}
+		return 1, hash
+	}
+	parts := strings.SplitN(hash[1:], ":", 2)
+	if len(parts) != 2 {
+		return 0, ""
+	}
+	version, err := strconv.Atoi(parts[0])
+	if err != nil || version < 2 {
+		return 0, ""
+	}
+	return version, parts[1]
+}
+
+// canonicalService returns the set keys of a service configuration as json,
+// whose mapping keys are sorted, with the unordered lists sorted too.
+func canonicalService(config *ServiceConfig) ([]byte, error) {
+	bytes, err := yamlv2.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[interface{}]interface{}
+	if err := yamlv2.Unmarshal(bytes, &raw); err != nil {
+		return nil, err
+	}
+	service := map[string]interface{}{}
+	for key, value := range raw {
+		name := fmt.Sprint(key)
+		if unhashedKeys[name] {
+			continue
+		}
+		value = canonicalValue(value)
+		if list, ok := value.([]interface{}); ok && keyedKeys[name] {
+			value = keyedMapping(list)
+		}
+		if list, ok := value.([]interface{}); ok && unorderedKeys[name] {
+			sort.Slice(list, func(i, j int) bool {
+				return fmt.Sprint(list[i]) < fmt.Sprint(list[j])
+			})
+		}
+		if !isUnsetValue(value) {
+			service[name] = value
+		}
+	}
+	return json.Marshal(service)
+}
+
+func canonicalValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := map[string]interface{}{}
+		for key, item := range v {
+			result[fmt.Sprint(key)] = canonicalValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = canonicalValue(item)
+		}
+		return result
+	}
+	return value
+}
+
+// isUnsetValue reports whether a canonical value is null, the zero value of
+// its type or an empty collection.
+func isUnsetValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	}
+	return false
+}
+
+// legacyHashFields are the fields of ServiceConfig covered by the first
+// scheme, those it had then. Fields added since don't change legacy hashes.
+var legacyHashFields = map[string]bool{
+	"Build": true, "CapAdd": true, "CapDrop": true, "CPUSet": true, "CPUShares": true,
+	"CPUQuota": true, "Command": true, "CgroupParent": true, "ContainerName": true,
+	"Devices": true, "DependsOn": true, "DNS": true, "DNSOpts": true, "DNSSearch": true,
+	"DomainName": true, "Entrypoint": true, "EnvFile": true, "Environment": true,
+	"Expose": true, "Extends": true, "ExternalLinks": true, "ExtraHosts": true,
+	"GroupAdd": true, "Image": true, "Isolation": true, "Hostname": true, "Ipc": true,
+	"Labels": true, "Links": true, "Logging": true, "MacAddress": true, "MemLimit": true,
+	"MemReservation": true, "MemSwapLimit": true, "MemSwappiness": true,
+	"NetworkMode": true, "Networks": true, "OomKillDisable": true, "OomScoreAdj": true,
+	"Pid": true, "Ports": true, "Privileged": true, "SecurityOpt": true, "ShmSize": true,
+	"StopGracePeriod": true, "StopSignal": true, "Tmpfs": true, "VolumeDriver": true,
+	"Volumes": true, "VolumesFrom": true, "Uts": true, "Restart": true, "ReadOnly": true,
+	"StdinOpen": true, "Tty": true, "User": true, "WorkingDir": true, "Ulimits": true,
+}
+
+// keyedMapping returns a list of KEY=VALUE as a mapping, items without a value
+// like "KEY" being null.
+func keyedMapping(list []interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, item := range list {
+		parts := strings.SplitN(fmt.Sprint(item), "=", 2)
+		if len(parts) == 2 {
+			result[parts[0]] = parts[1]
+		} else {
+			result[parts[0]] = nil
+		}
+	}
+	return result
+}
+
+// GetLegacyServiceHash computes the hash of a service with the first scheme,
+// that of GetServiceHash before versioning. It covers the legacyHashFields of
+// ServiceConfig, and is only kept to recognize existing containers.
+func GetLegacyServiceHash(name string, config *ServiceConfig) string {
 	hash := sha1.New()
 
 	io.WriteString(hash, name)
@@ -32,6 +234,9 @@ func GetServiceHash(name string, config *ServiceConfig) string {
 	for i := 0; i < val.NumField(); i++ {
 		valueField := val.Field(i)
 		keyField := val.Type().Field(i)
+		if !legacyHashFields[keyField.Name] {
+			continue
+		}
 
 		serviceKeys = append(serviceKeys, keyField.Name)
 		unsortedKeyValue[keyField.Name] = valueField.Interface()
@@ -74,13 +279,14 @@ func GetServiceHash(name string, config *ServiceConfig) string {
 				io.WriteString(hash, fmt.Sprintf("%s, ", sliceKey))
 			}
 		case yaml.Stringorslice:
-			sort.Strings(s)
+			sliceKeys := append([]string{}, s...)
+			sort.Strings(sliceKeys)
 
-			for _, sliceKey := range s {
+			for _, sliceKey := range sliceKeys {
 				io.WriteString(hash, fmt.Sprintf("%s, ", sliceKey))
 			}
 		case []string:
-			sliceKeys := s
+			sliceKeys := append([]string{}, s...)
 			sort.Strings(sliceKeys)
 
 			for _, sliceKey := range sliceKeys {
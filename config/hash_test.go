@@ -0,0 +1,107 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	yamlTypes "github.com/docker/libcompose/yaml"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func parseServiceConfig(t *testing.T, content string) *ServiceConfig {
+	config := &ServiceConfig{}
+	if err := yaml.Unmarshal([]byte(content), config); err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestGetServiceHash(t *testing.T) {
+	config := parseServiceConfig(t, `
+image: nginx
+ports: ["80:80", "443:443"]
+environment: [DEBUG=true, LEVEL=info]
+mem_limit: 512m
+`)
+	hash := GetServiceHash("web", config)
+	assert.True(t, strings.HasPrefix(hash, "v2:"), hash)
+	// The hash must not change between libcompose versions
+	assert.Equal(t, "v2:4dca6e32ff9b9dd5d8df30df96ad660b728903b6831f6cc3cc466d65da81d63b", hash)
+	assert.Equal(t, hash, GetServiceHash("web", config))
+	assert.NotEqual(t, hash, GetServiceHash("api", config))
+
+	for _, same := range []string{
+		"image: nginx\nports: [\"443:443\", \"80:80\"]\nenvironment: {LEVEL: info, DEBUG: \"true\"}\nmem_limit: 536870912\n",
+		"image: nginx\nports: [\"80:80\", \"443:443\"]\nenvironment: [DEBUG=true, LEVEL=info]\nmem_limit: 512m\nprivileged: false\ncap_add: []\n",
+		"image: nginx\nports: [\"80:80\", \"443:443\"]\nenvironment: [DEBUG=true, LEVEL=info]\nmem_limit: 512m\nx-update-config: {parallelism: 1}\n",
+	} {
+		assert.Equal(t, hash, GetServiceHash("web", parseServiceConfig(t, same)), same)
+	}
+
+	for _, different := range []string{
+		"image: nginx\nports: [\"80:80\"]\nenvironment: [DEBUG=true, LEVEL=info]\nmem_limit: 512m\n",
+		"image: nginx\nports: [\"80:80\", \"443:443\"]\nenvironment: [DEBUG=false, LEVEL=info]\nmem_limit: 512m\n",
+		"image: nginx\nports: [\"80:80\", \"443:443\"]\nenvironment: [DEBUG=true, LEVEL=info]\nmem_limit: 1g\n",
+		"image: nginx\nports: [\"80:80\", \"443:443\"]\nenvironment: [DEBUG=true, LEVEL=info]\nmem_limit: 512m\nprivileged: true\n",
+	} {
+		assert.NotEqual(t, hash, GetServiceHash("web", parseServiceConfig(t, different)), different)
+	}
+
+	command := GetServiceHash("web", &ServiceConfig{Command: yamlTypes.Command{"run", "--fast"}})
+	assert.NotEqual(t, command, GetServiceHash("web", &ServiceConfig{Command: yamlTypes.Command{"--fast", "run"}}))
+}
+
+func TestServiceHashMatches(t *testing.T) {
+	config := &ServiceConfig{
+		Image:       "nginx:1.11",
+		DNS:         yamlTypes.Stringorslice{"8.8.8.8", "1.1.1.1"},
+		Environment: yamlTypes.MaporEqualSlice{"DEBUG=true", "LEVEL=info"},
+		Labels:      yamlTypes.SliceorMap{"com.example.tier": "front"},
+		Ports:       []string{"80:80"},
+		MemLimit:    yamlTypes.MemStringorInt(512 * 1024 * 1024),
+		Privileged:  true,
+	}
+
+	// Hashes of containers created by libcompose before the hash was
+	// versioned, they must keep matching
+	for legacy, config := range map[string]*ServiceConfig{
+		"185ccdf0781ece15204433df8cbce28e8b465c8b": {Image: "nginx"},
+		"531230fcb696d8607b09331a0d56b55d1655bd34": config,
+	} {
+		assert.Equal(t, legacy, GetLegacyServiceHash("web", config))
+		assert.True(t, ServiceHashMatches("web", config, legacy))
+
+		config.HealthCheck = HealthCheck{Test: yamlTypes.Stringorslice{"true"}}
+		config.UpdateConfig = UpdateConfig{Parallelism: 1}
+		assert.True(t, ServiceHashMatches("web", config, legacy))
+	}
+	assert.True(t, ServiceHashMatches("web", config, GetServiceHash("web", config)))
+
+	changed := &ServiceConfig{Image: "nginx:1.13"}
+	assert.False(t, ServiceHashMatches("web", changed, "185ccdf0781ece15204433df8cbce28e8b465c8b"))
+	assert.False(t, ServiceHashMatches("web", changed, GetServiceHash("web", config)))
+	assert.False(t, ServiceHashMatches("web", config, ""))
+	assert.False(t, ServiceHashMatches("web", config, "v3:531230fcb696d8607b09331a0d56b55d1655bd34"))
+}
+
+func TestParseServiceHash(t *testing.T) {
+	legacy := GetLegacyServiceHash("web", &ServiceConfig{})
+	for hash, expected := range map[string]struct {
+		version int
+		digest  string
+	}{
+		legacy:       {1, legacy},
+		"v2:abcdef":  {2, "abcdef"},
+		"v10:abcdef": {10, "abcdef"},
+		"v1:abcdef":  {0, ""},
+		"vx:abcdef":  {0, ""},
+		"v2":         {0, ""},
+		"abcdef":     {0, ""},
+		"":           {0, ""},
+	} {
+		version, digest := ParseServiceHash(hash)
+		assert.Equal(t, expected.version, version, hash)
+		assert.Equal(t, expected.digest, digest, hash)
+	}
+}
@@ -143,6 +143,13 @@ func parseConfig(key string, data *interface{}, mapping func(string) string) err
 		*data, success = parseLine(typedData, mapping)
 
 		if !success {
+			// The values of environment variables may be secrets
+			if key == "environment" {
+				return fmt.Errorf("Invalid interpolation format for environment variable \"%s\"", strings.SplitN(typedData, "=", 2)[0])
+			}
+			if strings.HasPrefix(key, "environment.") {
+				return fmt.Errorf("Invalid interpolation format for environment variable \"%s\"", strings.TrimPrefix(key, "environment."))
+			}
 			return fmt.Errorf("Invalid interpolation format for key \"%s\": \"%s\"", key, typedData)
 		}
 	case []interface{}:
@@ -157,7 +164,11 @@ func parseConfig(key string, data *interface{}, mapping func(string) string) err
 		}
 	case map[interface{}]interface{}:
 		for k, v := range typedData {
-			err := parseConfig(key, &v, mapping)
+			childKey := key
+			if key == "environment" {
+				childKey = fmt.Sprintf("%s.%v", key, k)
+			}
+			err := parseConfig(childKey, &v, mapping)
 
 			if err != nil {
 				return err
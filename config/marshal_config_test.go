@@ -1,8 +1,11 @@
 package config
 
 import (
+	"reflect"
+	"sort"
 	"testing"
 
+	"github.com/docker/libcompose/utils"
 	yamlTypes "github.com/docker/libcompose/yaml"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v2"
@@ -111,3 +114,167 @@ func TestMarshalServiceConfig(t *testing.T) {
 
 	assert.Equal(t, configPtr, configPtr2)
 }
+
+func newFullServiceConfig() *ServiceConfig {
+	arg := "1.11"
+	return &ServiceConfig{
+		Build: yamlTypes.Build{
+			Context:    ".",
+			Dockerfile: "Dockerfile.web",
+			Args:       map[string]*string{"VERSION": &arg},
+		},
+		CapAdd:        []string{"NET_ADMIN"},
+		CapDrop:       []string{"MKNOD"},
+		CPUSet:        "0,1",
+		CPUShares:     512,
+		CPUQuota:      50000,
+		Command:       yamlTypes.Command{"bash", "-c", "echo $$HOME"},
+		CgroupParent:  "m-executor",
+		ContainerName: "web",
+		Devices:       []string{"/dev/ttyUSB0:/dev/ttyUSB0"},
+		DependsOn:     []string{"db"},
+		DNS:           yamlTypes.Stringorslice{"8.8.8.8"},
+		DNSOpts:       []string{"use-vc"},
+		DNSSearch:     yamlTypes.Stringorslice{"example.com"},
+		DomainName:    "example.com",
+		Entrypoint:    yamlTypes.Command{"/entrypoint.sh", "--verbose"},
+		EnvFile:       yamlTypes.Stringorslice{"web.env"},
+		Environment:   yamlTypes.MaporEqualSlice{"DEBUG=true", "EMPTY=", "UNSET"},
+		Expose:        []string{"3000"},
+		Extends:       yamlTypes.MaporEqualSlice{"file=common.yml", "service=base"},
+		ExternalLinks: []string{"redis_1"},
+		ExtraHosts:    []string{"somehost:162.242.195.82"},
+		GroupAdd:      []string{"mail"},
+		HealthCheck: HealthCheck{
+			Test:     yamlTypes.Stringorslice{"CMD", "curl", "-f", "http://localhost"},
+			Interval: "30s",
+			Timeout:  "10s",
+			Retries:  3,
+			Disable:  true,
+		},
+		Image:     "nginx:1.11",
+		Isolation: "default",
+		Hostname:  "web",
+		Ipc:       "host",
+		Labels:    yamlTypes.SliceorMap{"com.example.tier": "front"},
+		Links:     yamlTypes.MaporColonSlice{"db:database"},
+		Logging: Log{
+			Driver:  "syslog",
+			Options: map[string]string{"syslog-address": "tcp://192.168.0.42:123"},
+		},
+		MacAddress:     "02:42:ac:11:65:43",
+		MemLimit:       512 * 1024 * 1024,
+		MemReservation: 1000,
+		MemSwapLimit:   1024 * 1024 * 1024,
+		MemSwappiness:  60,
+		NetworkMode:    "bridge",
+		Networks: &yamlTypes.Networks{
+			Networks: []*yamlTypes.Network{
+				{Name: "back"},
+				{Name: "front", Aliases: []string{"www"}, IPv4Address: "172.16.238.10"},
+			},
+		},
+		OomKillDisable:  true,
+		OomScoreAdj:     -500,
+		Pid:             "host",
+		Ports:           []string{"80:80", "443:443"},
+		Privileged:      true,
+		SecurityOpt:     []string{"label:user:USER"},
+		ShmSize:         64 * 1024 * 1024,
+		StopGracePeriod: "1m30s",
+		StopSignal:      "SIGUSR1",
+		Tmpfs:           yamlTypes.Stringorslice{"/run"},
+		VolumeDriver:    "local",
+		Volumes: &yamlTypes.Volumes{
+			Volumes: []*yamlTypes.Volume{
+				{Source: "data", Destination: "/data", AccessMode: "ro"},
+				{Destination: "/cache"},
+			},
+		},
+		VolumesFrom: []string{"service_name"},
+		Uts:         "host",
+		Restart:     "always",
+		ReadOnly:    true,
+		StdinOpen:   true,
+		Tty:         true,
+		User:        "nobody",
+		WorkingDir:  "/srv",
+		Ulimits: yamlTypes.Ulimits{
+			Elements: []yamlTypes.Ulimit{
+				yamlTypes.NewUlimit("nofile", 20000, 40000),
+				yamlTypes.NewUlimit("nproc", 65535, 65535),
+			},
+		},
+		UpdateConfig: UpdateConfig{
+			Parallelism:   2,
+			Delay:         "10s",
+			Order:         UpdateOrderStartFirst,
+			FailureAction: UpdateFailureContinue,
+		},
+	}
+}
+
+func TestServiceConfigRoundTrip(t *testing.T) {
+	config := newFullServiceConfig()
+
+	// Every field must be set, so that new fields get covered too
+	value := reflect.ValueOf(config).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		zero := reflect.Zero(value.Field(i).Type()).Interface()
+		assert.False(t, reflect.DeepEqual(zero, value.Field(i).Interface()), "%s is not set", value.Type().Field(i).Name)
+	}
+
+	bytes, err := yaml.Marshal(config)
+	assert.Nil(t, err)
+
+	parsed := &ServiceConfig{}
+	assert.Nil(t, yaml.Unmarshal(bytes, parsed))
+	sortNetworks(parsed)
+	assert.Equal(t, config, parsed)
+
+	bytes2, err := yaml.Marshal(parsed)
+	assert.Nil(t, err)
+	assert.Equal(t, string(bytes), string(bytes2))
+
+	// Existing services are merged back in their raw form
+	var raw RawService
+	assert.Nil(t, utils.Convert(config, &raw))
+	converted := &ServiceConfig{}
+	assert.Nil(t, utils.Convert(raw, converted))
+	sortNetworks(converted)
+	assert.Equal(t, config, converted)
+}
+
+// sortNetworks sorts the networks of a service by name, as they are parsed
+// from a mapping in any order.
+func sortNetworks(config *ServiceConfig) {
+	if config.Networks == nil {
+		return
+	}
+	sort.Slice(config.Networks.Networks, func(i, j int) bool {
+		return config.Networks.Networks[i].Name < config.Networks.Networks[j].Name
+	})
+}
+
+func TestServiceConfigCanonical(t *testing.T) {
+	config := &ServiceConfig{}
+	assert.Nil(t, yaml.Unmarshal([]byte(`
+command: npm start
+environment: [NODE_ENV=production, DEBUG=]
+mem_limit: 536870912
+cpu_shares: "512"
+links: [db]
+`), config))
+
+	bytes, err := yaml.Marshal(config)
+	assert.Nil(t, err)
+	assert.Equal(t, `cpu_shares: 512
+command: [npm, start]
+environment:
+- DEBUG=
+- NODE_ENV=production
+links:
+- db
+mem_limit: 512m
+`, string(bytes))
+}
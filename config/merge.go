@@ -27,6 +27,16 @@ var (
 	}
 )
 
+// DefaultParseOptions returns the options Merge uses when none are given, to
+// build upon.
+func DefaultParseOptions() ParseOptions {
+	return defaultParseOptions
+}
+
+// ServiceDefaultsKey is the top-level key holding the values merged under
+// every service of a compose file.
+const ServiceDefaultsKey = "x-service-defaults"
+
 func getComposeMajorVersion(version string) (int, error) {
 	if version == "" {
 		return 1, nil
@@ -58,10 +68,20 @@ func CreateConfig(bytes []byte) (*Config, error) {
 		return nil, err
 	}
 	if major < 2 {
+		var raw map[string]interface{}
+		if err := yaml.Unmarshal(bytes, &raw); err != nil {
+			return nil, err
+		}
+		// x-sensitive sits next to the services but isn't one
+		delete(raw, SensitiveKey)
 		var baseRawServices RawServiceMap
-		if err := yaml.Unmarshal(bytes, &baseRawServices); err != nil {
+		if err := utils.Convert(raw, &baseRawServices); err != nil {
 			return nil, err
 		}
+		if defaults, ok := baseRawServices[ServiceDefaultsKey]; ok {
+			config.ServiceDefaults = defaults
+			delete(baseRawServices, ServiceDefaultsKey)
+		}
 		config.Services = baseRawServices
 	}
 
@@ -87,6 +107,15 @@ func Merge(existingServices *ServiceConfigs, environmentLookup EnvironmentLookup
 	}
 	baseRawServices := config.Services
 
+	if options.Provenance != nil {
+		if err := options.Provenance.begin(file, bytes, resourceLookup); err != nil {
+			return "", nil, nil, nil, err
+		}
+	}
+	if options.Sensitive != nil {
+		options.Sensitive.addNames(config.Sensitive)
+	}
+
 	for service, data := range baseRawServices {
 		for key, value := range data {
 			//check for "extends" key and check whether it is string or not
@@ -117,6 +146,13 @@ func Merge(existingServices *ServiceConfigs, environmentLookup EnvironmentLookup
 			}
 			config.Networks[k] = v
 		}
+
+		for k, v := range config.ServiceDefaults {
+			if err := Interpolate(k, &v, environmentLookup); err != nil {
+				return "", nil, nil, nil, err
+			}
+			config.ServiceDefaults[k] = v
+		}
 	}
 
 	if options.Preprocess != nil {
@@ -138,12 +174,12 @@ func Merge(existingServices *ServiceConfigs, environmentLookup EnvironmentLookup
 		logrus.Fatal("Note: Compose file version 3 is not yet implemented")
 	case 2:
 		var err error
-		serviceConfigs, err = MergeServicesV2(existingServices, environmentLookup, resourceLookup, file, baseRawServices, options)
+		serviceConfigs, err = mergeServicesV2(existingServices, environmentLookup, resourceLookup, file, baseRawServices, config.ServiceDefaults, options)
 		if err != nil {
 			return "", nil, nil, nil, err
 		}
 	default:
-		serviceConfigsV1, err := MergeServicesV1(existingServices, environmentLookup, resourceLookup, file, baseRawServices, options)
+		serviceConfigsV1, err := mergeServicesV1(existingServices, environmentLookup, resourceLookup, file, baseRawServices, config.ServiceDefaults, options)
 		if err != nil {
 			return "", nil, nil, nil, err
 		}
@@ -197,7 +233,7 @@ func adjustValues(configs map[string]*ServiceConfig) {
 	}
 }
 
-func readEnvFile(resourceLookup ResourceLookup, inFile string, serviceData RawService) (RawService, error) {
+func readEnvFile(resourceLookup ResourceLookup, inFile string, serviceData RawService, options *ParseOptions) (RawService, error) {
 	if _, ok := serviceData["env_file"]; !ok {
 		return serviceData, nil
 	}
@@ -252,6 +288,11 @@ func readEnvFile(resourceLookup ResourceLookup, inFile string, serviceData RawSe
 
 				if !found {
 					vars = append(vars, line)
+					if options.Sensitive != nil {
+						if parts := strings.SplitN(line, "=", 2); len(parts) == 2 {
+							options.Sensitive.addValue(parts[1])
+						}
+					}
 				}
 			}
 		}
@@ -266,6 +307,67 @@ func readEnvFile(resourceLookup ResourceLookup, inFile string, serviceData RawSe
 	return serviceData, nil
 }
 
+// ProjectDefaults records the x-service-defaults of the compose files merged
+// one after the other, which also apply to the services the following files
+// define.
+type ProjectDefaults struct {
+	defaults RawService
+}
+
+// NewProjectDefaults creates an empty ProjectDefaults, to set in ParseOptions.
+func NewProjectDefaults() *ProjectDefaults {
+	return &ProjectDefaults{}
+}
+
+// add merges the defaults of a compose file over those of the previous files,
+// and returns the result.
+func (d *ProjectDefaults) add(fileDefaults RawService) RawService {
+	if len(fileDefaults) > 0 {
+		if d.defaults == nil {
+			d.defaults = RawService{}
+		}
+		d.defaults = mergeConfig(d.defaults, deepCopy(fileDefaults).(RawService))
+	}
+	return d.defaults
+}
+
+// serviceDefaults returns the defaults of a compose file, whose env_file and
+// build context are resolved against it, merged over the defaults of the
+// previous files and of the parse options.
+func serviceDefaults(resourceLookup ResourceLookup, file string, fileDefaults RawService, resolveContext func(string, RawService) RawService, options *ParseOptions) (RawService, error) {
+	if len(fileDefaults) > 0 {
+		resolved, err := readEnvFile(resourceLookup, file, resolveContext(file, deepCopy(fileDefaults).(RawService)), options)
+		if err != nil {
+			return nil, err
+		}
+		// The environment read is typed, it merges as raw values
+		fileDefaults = nil
+		if err := utils.Convert(resolved, &fileDefaults); err != nil {
+			return nil, err
+		}
+	}
+	if options.ProjectDefaults != nil {
+		fileDefaults = options.ProjectDefaults.add(fileDefaults)
+	}
+
+	var defaults RawService
+	if options.Defaults != nil {
+		if err := utils.Convert(options.Defaults, &defaults); err != nil {
+			return nil, err
+		}
+	}
+	if len(fileDefaults) > 0 {
+		if defaults == nil {
+			defaults = RawService{}
+		}
+		defaults = mergeConfig(defaults, deepCopy(fileDefaults).(RawService))
+	}
+	if len(defaults) == 0 {
+		return nil, nil
+	}
+	return defaults, nil
+}
+
 func mergeConfig(baseService, serviceData RawService) RawService {
 	for k, v := range serviceData {
 		existing, ok := baseService[k]
@@ -2,7 +2,11 @@ package config
 
 import (
 	"io/ioutil"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/docker/libcompose/yaml"
 )
 
 type NullLookup struct {
@@ -345,6 +349,88 @@ services:
 	}
 }
 
+func TestHealthCheck(t *testing.T) {
+	_, configs, _, _, err := Merge(NewServiceConfigs(), nil, &NullLookup{}, "", []byte(`
+version: '2'
+services:
+  shell:
+    image: foo
+    healthcheck:
+      test: curl -f http://localhost
+      interval: 30s
+      retries: 3
+  exec:
+    image: foo
+    healthcheck:
+      test: ["CMD", "pg_isready"]
+  disabled:
+    image: foo
+    healthcheck:
+      disable: true
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shell := configs["shell"].HealthCheck
+	if !reflect.DeepEqual(shell.TestCommand(), []string{"CMD-SHELL", "curl -f http://localhost"}) || shell.Interval != "30s" || shell.Retries != 3 {
+		t.Fatal("Invalid healthcheck", shell)
+	}
+	if test := configs["exec"].HealthCheck.TestCommand(); !reflect.DeepEqual(test, []string{"CMD", "pg_isready"}) {
+		t.Fatal("Invalid healthcheck test", test)
+	}
+	if test := configs["disabled"].HealthCheck.TestCommand(); !reflect.DeepEqual(test, []string{"NONE"}) {
+		t.Fatal("Invalid healthcheck test", test)
+	}
+}
+
+func TestUpdateConfig(t *testing.T) {
+	_, configs, _, _, err := Merge(NewServiceConfigs(), nil, &NullLookup{}, "", []byte(`
+version: '2'
+services:
+  web:
+    image: foo
+    x-update-config:
+      parallelism: 2
+      delay: 10s
+      order: start-first
+      failure_action: continue
+      monitor: 30s
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := UpdateConfig{Parallelism: 2, Delay: "10s", Order: UpdateOrderStartFirst, FailureAction: UpdateFailureContinue, Monitor: "30s"}
+	if updateConfig := configs["web"].UpdateConfig; updateConfig != expected {
+		t.Fatal("Invalid update config", updateConfig)
+	}
+	if delay, err := expected.DelayDuration(); err != nil || delay != 10*time.Second {
+		t.Fatal("Invalid update delay", delay, err)
+	}
+	if monitor, err := expected.MonitorDuration(); err != nil || monitor != 30*time.Second {
+		t.Fatal("Invalid update monitor", monitor, err)
+	}
+	if monitor, err := (UpdateConfig{}).MonitorDuration(); err != nil || monitor != DefaultUpdateMonitor {
+		t.Fatal("Invalid default update monitor", monitor, err)
+	}
+	if _, err := (UpdateConfig{Monitor: "0s"}).MonitorDuration(); err == nil {
+		t.Fatal("Expected a zero monitor to fail")
+	}
+
+	_, _, _, _, err = Merge(NewServiceConfigs(), nil, &NullLookup{}, "", []byte(`
+version: '2'
+services:
+  web:
+    image: foo
+    x-update-config:
+      order: random
+`), nil)
+	if err == nil {
+		t.Fatal("Expected an invalid order to fail")
+	}
+}
+
 func TestIsValidRemote(t *testing.T) {
 	gitUrls := []string{
 		"git://github.com/docker/docker",
@@ -440,3 +526,173 @@ services:
 		}
 	}
 }
+
+func TestServiceDefaults(t *testing.T) {
+	parseOptions := ParseOptions{
+		Interpolate: true,
+		Validate:    true,
+		Defaults: &ServiceConfig{
+			Restart: "always",
+			Labels:  map[string]string{"team": "core"},
+		},
+	}
+
+	configs := NewServiceConfigs()
+	_, services, _, _, err := Merge(configs, nil, &NullLookup{}, "", []byte(`
+version: '2'
+x-service-defaults:
+  restart: unless-stopped
+  logging:
+    driver: syslog
+  labels:
+    tier: backend
+services:
+  web:
+    image: nginx
+    labels:
+      tier: frontend
+  db:
+    image: postgres
+    logging:
+      driver: json-file
+  worker:
+    extends:
+      service: db
+    command: run
+`), &parseOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, service := range services {
+		configs.Add(name, service)
+	}
+
+	web := services["web"]
+	if web.Restart != "unless-stopped" || web.Logging.Driver != "syslog" {
+		t.Fatal("Defaults were not applied", web.Restart, web.Logging)
+	}
+	if !reflect.DeepEqual(web.Labels, yaml.SliceorMap{"team": "core", "tier": "frontend"}) {
+		t.Fatal("Service labels should be merged over the defaults", web.Labels)
+	}
+	db := services["db"]
+	if db.Logging.Driver != "json-file" {
+		t.Fatal("Service values should win over the defaults", db.Logging)
+	}
+	worker := services["worker"]
+	if worker.Logging.Driver != "json-file" || worker.Restart != "unless-stopped" {
+		t.Fatal("Extended values should win over the defaults", worker.Logging, worker.Restart)
+	}
+
+	// Services already defined by a previous file are not reset to the defaults
+	_, services, _, _, err = Merge(configs, nil, &NullLookup{}, "", []byte(`
+version: '2'
+x-service-defaults:
+  restart: "no"
+services:
+  web:
+    command: serve
+  cache:
+    image: redis
+`), &parseOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	web = services["web"]
+	if web.Restart != "unless-stopped" || web.Command[0] != "serve" {
+		t.Fatal("Override file should not apply its defaults to existing services", web.Restart, web.Command)
+	}
+	cache := services["cache"]
+	if cache.Restart != "no" || cache.Labels["team"] != "core" {
+		t.Fatal("Defaults were not applied", cache.Restart, cache.Labels)
+	}
+}
+
+func TestServiceDefaultsAcrossFiles(t *testing.T) {
+	parseOptions := ParseOptions{
+		Interpolate:     true,
+		Validate:        true,
+		ProjectDefaults: NewProjectDefaults(),
+	}
+
+	configs := NewServiceConfigs()
+	_, services, _, _, err := Merge(configs, nil, &FileLookup{}, "app/docker-compose.yml", []byte(`
+version: '2'
+x-service-defaults:
+  build: ./images/base
+  env_file: testdata/.env
+  restart: always
+services:
+  web:
+    environment:
+      - FOO=web
+`), &parseOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, service := range services {
+		configs.Add(name, service)
+	}
+
+	web := services["web"]
+	if web.Build.Context != "app/images/base" {
+		t.Fatal("Defaults build context should be resolved against their file", web.Build.Context)
+	}
+	if !reflect.DeepEqual(web.Environment.ToMap(), map[string]string{"FOO": "web", "BAR": "bar"}) {
+		t.Fatal("Defaults env_file should be read under the service environment", web.Environment)
+	}
+
+	// The services of an override file get the defaults of the previous files
+	_, services, _, _, err = Merge(configs, nil, &FileLookup{}, "override/docker-compose.yml", []byte(`
+version: '2'
+services:
+  worker:
+    command: work
+`), &parseOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worker := services["worker"]
+	if worker.Restart != "always" || worker.Build.Context != "app/images/base" || worker.Environment.ToMap()["FOO"] != "foo" {
+		t.Fatal("Defaults of the previous files were not applied", worker.Restart, worker.Build.Context, worker.Environment)
+	}
+}
+
+func TestServiceDefaultsV1(t *testing.T) {
+	_, configs, _, _, err := Merge(NewServiceConfigs(), nil, &NullLookup{}, "", []byte(`
+x-service-defaults:
+  image: busybox
+  restart: always
+web:
+  build: .
+db:
+  restart: "no"
+`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := configs[ServiceDefaultsKey]; ok {
+		t.Fatal("Defaults should not be parsed as a service")
+	}
+	if web := configs["web"]; web.Image != "" || web.Restart != "always" {
+		t.Fatal("Defaults image should not be applied to a built service", web.Image, web.Restart)
+	}
+	if db := configs["db"]; db.Image != "busybox" || db.Restart != "no" {
+		t.Fatal("Defaults were not applied", db.Image, db.Restart)
+	}
+}
+
+func TestServiceDefaultsValidation(t *testing.T) {
+	_, _, _, _, err := Merge(NewServiceConfigs(), nil, &NullLookup{}, "", []byte(`
+version: '2'
+x-service-defaults:
+  restrat: always
+services:
+  web:
+    image: nginx
+`), nil)
+	if err == nil {
+		t.Fatal("Invalid defaults should fail validation")
+	}
+}
@@ -10,10 +10,24 @@ import (
 
 // MergeServicesV1 merges a v1 compose file into an existing set of service configs
 func MergeServicesV1(existingServices *ServiceConfigs, environmentLookup EnvironmentLookup, resourceLookup ResourceLookup, file string, datas RawServiceMap, options *ParseOptions) (map[string]*ServiceConfigV1, error) {
+	return mergeServicesV1(existingServices, environmentLookup, resourceLookup, file, datas, nil, options)
+}
+
+func mergeServicesV1(existingServices *ServiceConfigs, environmentLookup EnvironmentLookup, resourceLookup ResourceLookup, file string, datas RawServiceMap, fileDefaults RawService, options *ParseOptions) (map[string]*ServiceConfigV1, error) {
+	defaults, err := serviceDefaults(resourceLookup, file, fileDefaults, resolveContextV1, options)
+	if err != nil {
+		return nil, err
+	}
+
 	if options.Validate {
 		if err := validate(datas); err != nil {
 			return nil, err
 		}
+		if defaults != nil {
+			if err := validate(RawServiceMap{ServiceDefaultsKey: defaults}); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	for name, data := range datas {
@@ -29,7 +43,17 @@ func MergeServicesV1(existingServices *ServiceConfigs, environmentLookup Environ
 				return nil, err
 			}
 
+			if options.Provenance != nil {
+				options.Provenance.record(name, data, rawExistingService, nil)
+			}
 			data = mergeConfigV1(rawExistingService, data)
+		} else {
+			if defaults != nil {
+				data = mergeConfigV1(deepCopy(defaults).(RawService), data)
+			}
+			if options.Provenance != nil {
+				options.Provenance.record(name, data, nil, defaults)
+			}
 		}
 
 		datas[name] = data
@@ -53,7 +77,7 @@ func MergeServicesV1(existingServices *ServiceConfigs, environmentLookup Environ
 }
 
 func parseV1(resourceLookup ResourceLookup, environmentLookup EnvironmentLookup, inFile string, serviceData RawService, datas RawServiceMap, options *ParseOptions) (RawService, error) {
-	serviceData, err := readEnvFile(resourceLookup, inFile, serviceData)
+	serviceData, err := readEnvFile(resourceLookup, inFile, serviceData, options)
 	if err != nil {
 		return nil, err
 	}
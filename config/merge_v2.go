@@ -11,10 +11,24 @@ import (
 
 // MergeServicesV2 merges a v2 compose file into an existing set of service configs
 func MergeServicesV2(existingServices *ServiceConfigs, environmentLookup EnvironmentLookup, resourceLookup ResourceLookup, file string, datas RawServiceMap, options *ParseOptions) (map[string]*ServiceConfig, error) {
+	return mergeServicesV2(existingServices, environmentLookup, resourceLookup, file, datas, nil, options)
+}
+
+func mergeServicesV2(existingServices *ServiceConfigs, environmentLookup EnvironmentLookup, resourceLookup ResourceLookup, file string, datas RawServiceMap, fileDefaults RawService, options *ParseOptions) (map[string]*ServiceConfig, error) {
+	defaults, err := serviceDefaults(resourceLookup, file, fileDefaults, resolveContextV2, options)
+	if err != nil {
+		return nil, err
+	}
+
 	if options.Validate {
 		if err := validateV2(datas); err != nil {
 			return nil, err
 		}
+		if defaults != nil {
+			if err := validateV2(RawServiceMap{ServiceDefaultsKey: defaults}); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	for name, data := range datas {
@@ -30,7 +44,17 @@ func MergeServicesV2(existingServices *ServiceConfigs, environmentLookup Environ
 				return nil, err
 			}
 
+			if options.Provenance != nil {
+				options.Provenance.record(name, data, rawExistingService, nil)
+			}
 			data = mergeConfig(rawExistingService, data)
+		} else {
+			if defaults != nil {
+				data = mergeConfig(deepCopy(defaults).(RawService), data)
+			}
+			if options.Provenance != nil {
+				options.Provenance.record(name, data, nil, defaults)
+			}
 		}
 
 		datas[name] = data
@@ -58,7 +82,7 @@ func MergeServicesV2(existingServices *ServiceConfigs, environmentLookup Environ
 }
 
 func parseV2(resourceLookup ResourceLookup, environmentLookup EnvironmentLookup, inFile string, serviceData RawService, datas RawServiceMap, options *ParseOptions) (RawService, error) {
-	serviceData, err := readEnvFile(resourceLookup, inFile, serviceData)
+	serviceData, err := readEnvFile(resourceLookup, inFile, serviceData, options)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,456 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/docker/libcompose/utils"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Overlay is a patch applied to the services, volumes and networks of a
+// project once all its compose files are merged, and before they are
+// validated.
+//
+// An overlay file holding a sequence is a RFC 6902 JSON Patch, whose paths
+// start with /services, /volumes or /networks:
+//
+//	[{op: remove, path: /services/web/ports/0}]
+//
+// An overlay file holding a mapping is a strategic merge patch: mappings are
+// merged, sequences are replaced, a null value removes a key and a mapping
+// holding "$patch: delete" or "$patch: replace" removes or replaces the
+// value instead of merging it:
+//
+//	services:
+//	  web:
+//	    ports: ["8080:80"]
+//	    logging:
+//	      $patch: delete
+type Overlay struct {
+	File       string
+	operations []patchOperation
+	merge      map[interface{}]interface{}
+}
+
+type patchOperation struct {
+	Op    string      `yaml:"op"`
+	Path  string      `yaml:"path"`
+	From  string      `yaml:"from,omitempty"`
+	Value interface{} `yaml:"value,omitempty"`
+}
+
+const patchDirective = "$patch"
+
+var overlayKeys = []string{"services", "volumes", "networks"}
+
+// ParseOverlay parses the content of an overlay file, the file is only used
+// in error messages.
+func ParseOverlay(file string, content []byte) (*Overlay, error) {
+	overlay := &Overlay{File: file}
+
+	var document interface{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("Invalid overlay %s: %v", file, err)
+	}
+
+	switch document.(type) {
+	case nil:
+		return overlay, nil
+	case []interface{}:
+		if err := yaml.Unmarshal(content, &overlay.operations); err != nil {
+			return nil, fmt.Errorf("Invalid overlay %s: %v", file, err)
+		}
+		for i, operation := range overlay.operations {
+			switch operation.Op {
+			case "add", "remove", "replace", "move", "copy", "test":
+			default:
+				return nil, fmt.Errorf("Invalid overlay %s: operation %d has unsupported op '%s'", file, i, operation.Op)
+			}
+		}
+	case map[interface{}]interface{}:
+		overlay.merge = document.(map[interface{}]interface{})
+		for key := range overlay.merge {
+			if !utils.Contains(overlayKeys, fmt.Sprint(key)) {
+				return nil, fmt.Errorf("Invalid overlay %s: unsupported key '%v', expected one of %s", file, key, strings.Join(overlayKeys, ", "))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Invalid overlay %s: expected a list of JSON Patch operations or a mapping", file)
+	}
+
+	return overlay, nil
+}
+
+// Apply patches a document holding services, volumes and networks.
+func (o *Overlay) Apply(document map[interface{}]interface{}) error {
+	if o.merge != nil {
+		for key, patch := range o.merge {
+			if merged, keep := strategicMerge(document[key], patch); keep {
+				document[key] = merged
+			} else {
+				delete(document, key)
+			}
+		}
+		return nil
+	}
+
+	var root interface{} = document
+	for i, operation := range o.operations {
+		var err error
+		root, err = operation.apply(root)
+		if err != nil {
+			return fmt.Errorf("Failed to apply overlay %s: operation %d (%s %s): %v", o.File, i, operation.Op, operation.Path, err)
+		}
+	}
+	result, ok := root.(map[interface{}]interface{})
+	if !ok {
+		return fmt.Errorf("Failed to apply overlay %s: the document is not a mapping anymore", o.File)
+	}
+	if reflect.ValueOf(result).Pointer() != reflect.ValueOf(document).Pointer() {
+		// The whole document was replaced
+		for key := range document {
+			delete(document, key)
+		}
+		for key, value := range result {
+			document[key] = value
+		}
+	}
+	return nil
+}
+
+// ApplyOverlays applies the overlays to merged service, volume and network
+// configs, validates the result if requested by the options and returns the
+// patched configs.
+func ApplyOverlays(services map[string]*ServiceConfig, volumes map[string]*VolumeConfig, networks map[string]*NetworkConfig, overlays []*Overlay, options *ParseOptions) (map[string]*ServiceConfig, map[string]*VolumeConfig, map[string]*NetworkConfig, error) {
+	if options == nil {
+		options = &defaultParseOptions
+	}
+
+	bytes, err := yaml.Marshal(map[string]interface{}{
+		"services": services,
+		"volumes":  volumes,
+		"networks": networks,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var document map[interface{}]interface{}
+	if err := yaml.Unmarshal(bytes, &document); err != nil {
+		return nil, nil, nil, err
+	}
+
+	for _, overlay := range overlays {
+		if err := overlay.Apply(document); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	if bytes, err = yaml.Marshal(document); err != nil {
+		return nil, nil, nil, err
+	}
+	var patched Config
+	if err := yaml.Unmarshal(bytes, &patched); err != nil {
+		return nil, nil, nil, fmt.Errorf("Invalid configuration after applying overlays: %v", err)
+	}
+
+	for _, data := range patched.Services {
+		// Extends are resolved already, and their merged form isn't valid
+		delete(data, "extends")
+	}
+
+	if options.Validate {
+		if err := validateV2(patched.Services); err != nil {
+			return nil, nil, nil, err
+		}
+		var errs []string
+		for name, data := range patched.Services {
+			if err := validateServiceConstraintsv2(data, name); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		if len(errs) != 0 {
+			return nil, nil, nil, fmt.Errorf(strings.Join(errs, "\n"))
+		}
+	}
+
+	var patchedServices map[string]*ServiceConfig
+	var patchedVolumes map[string]*VolumeConfig
+	var patchedNetworks map[string]*NetworkConfig
+	if err := utils.Convert(patched.Services, &patchedServices); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := utils.Convert(patched.Volumes, &patchedVolumes); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := utils.Convert(patched.Networks, &patchedNetworks); err != nil {
+		return nil, nil, nil, err
+	}
+	return patchedServices, patchedVolumes, patchedNetworks, nil
+}
+
+// strategicMerge merges patch over base, it returns false if the value
+// should be removed.
+func strategicMerge(base, patch interface{}) (interface{}, bool) {
+	if patch == nil {
+		return nil, false
+	}
+	patchMap, ok := patch.(map[interface{}]interface{})
+	if !ok {
+		return patch, true
+	}
+
+	switch patchMap[patchDirective] {
+	case "delete":
+		return nil, false
+	case "replace":
+		return withoutDirectives(patchMap), true
+	}
+
+	var baseMap map[interface{}]interface{}
+	switch b := base.(type) {
+	case map[interface{}]interface{}:
+		baseMap = b
+	case []interface{}:
+		// Lists of KEY=VALUE, such as environment, merge with mappings
+		baseMap = equalSliceToMap(b)
+	}
+	if baseMap == nil {
+		return withoutDirectives(patchMap), true
+	}
+
+	result := map[interface{}]interface{}{}
+	for key, value := range baseMap {
+		result[key] = value
+	}
+	for key, value := range patchMap {
+		if key == patchDirective {
+			continue
+		}
+		if merged, keep := strategicMerge(result[key], value); keep {
+			result[key] = merged
+		} else {
+			delete(result, key)
+		}
+	}
+	return result, true
+}
+
+func withoutDirectives(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := map[interface{}]interface{}{}
+		for key, item := range v {
+			if key != patchDirective {
+				result[key] = withoutDirectives(item)
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = withoutDirectives(item)
+		}
+		return result
+	}
+	return value
+}
+
+// equalSliceToMap converts a list of KEY=VALUE strings to a mapping, it
+// returns nil if an item isn't a string.
+func equalSliceToMap(slice []interface{}) map[interface{}]interface{} {
+	result := map[interface{}]interface{}{}
+	for _, item := range slice {
+		s, ok := item.(string)
+		if !ok {
+			return nil
+		}
+		parts := strings.SplitN(s, "=", 2)
+		if len(parts) == 1 {
+			result[parts[0]] = nil
+		} else {
+			result[parts[0]] = parts[1]
+		}
+	}
+	return result
+}
+
+func (p patchOperation) apply(root interface{}) (interface{}, error) {
+	path, err := parsePointer(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.Op {
+	case "add":
+		return addValue(root, path, deepCopy(p.Value))
+	case "remove":
+		root, _, err := removeValue(root, path)
+		return root, err
+	case "replace":
+		if _, err := pointerValue(root, path); err != nil {
+			return nil, err
+		}
+		if root, _, err = removeValue(root, path); err != nil {
+			return nil, err
+		}
+		return addValue(root, path, deepCopy(p.Value))
+	case "move", "copy":
+		from, err := parsePointer(p.From)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if p.Op == "move" {
+			root, value, err = removeValue(root, from)
+		} else {
+			value, err = pointerValue(root, from)
+			value = deepCopy(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return addValue(root, path, value)
+	case "test":
+		value, err := pointerValue(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, p.Value) {
+			return nil, fmt.Errorf("Test failed, found %v", value)
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("Unsupported op '%s'", p.Op)
+}
+
+// parsePointer splits a RFC 6901 JSON Pointer in its reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("Invalid path '%s', it should start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+func pointerValue(root interface{}, path []string) (interface{}, error) {
+	value := root
+	for _, token := range path {
+		switch v := value.(type) {
+		case map[interface{}]interface{}:
+			item, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("Key '%s' not found", token)
+			}
+			value = item
+		case []interface{}:
+			index, err := sliceIndex(v, token, false)
+			if err != nil {
+				return nil, err
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf("Can't look up '%s' in a scalar", token)
+		}
+	}
+	return value, nil
+}
+
+// updateParent calls update with the container holding the last token of
+// path and stores the container it returns in place of the original one.
+func updateParent(root interface{}, path []string, update func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return update(root, path[0])
+	}
+	child, err := pointerValue(root, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = updateParent(child, path[1:], update)
+	if err != nil {
+		return nil, err
+	}
+	switch v := root.(type) {
+	case map[interface{}]interface{}:
+		v[path[0]] = child
+	case []interface{}:
+		index, _ := sliceIndex(v, path[0], false)
+		v[index] = child
+	}
+	return root, nil
+}
+
+func addValue(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(root, path, func(container interface{}, token string) (interface{}, error) {
+		switch v := container.(type) {
+		case map[interface{}]interface{}:
+			v[token] = value
+			return v, nil
+		case []interface{}:
+			index, err := sliceIndex(v, token, true)
+			if err != nil {
+				return nil, err
+			}
+			v = append(v, nil)
+			copy(v[index+1:], v[index:])
+			v[index] = value
+			return v, nil
+		}
+		return nil, fmt.Errorf("Can't add '%s' to a scalar", token)
+	})
+}
+
+func removeValue(root interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("Can't remove the whole document")
+	}
+	var removed interface{}
+	root, err := updateParent(root, path, func(container interface{}, token string) (interface{}, error) {
+		switch v := container.(type) {
+		case map[interface{}]interface{}:
+			item, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("Key '%s' not found", token)
+			}
+			removed = item
+			delete(v, token)
+			return v, nil
+		case []interface{}:
+			index, err := sliceIndex(v, token, false)
+			if err != nil {
+				return nil, err
+			}
+			removed = v[index]
+			return append(v[:index], v[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("Can't remove '%s' from a scalar", token)
+	})
+	return root, removed, err
+}
+
+// sliceIndex parses an array index of a JSON Pointer, "-" and the length of
+// the slice are only valid when adding an item.
+func sliceIndex(slice []interface{}, token string, adding bool) (int, error) {
+	if token == "-" && adding {
+		return len(slice), nil
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("Invalid index '%s'", token)
+	}
+	if index > len(slice) || index == len(slice) && !adding {
+		return 0, fmt.Errorf("Index %d out of range", index)
+	}
+	return index, nil
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var overlayServices = `
+version: '2'
+services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+      - "443:443"
+    environment:
+      DEBUG: "false"
+      LEVEL: info
+    logging:
+      driver: syslog
+    volumes:
+      - data:/data
+  db:
+    image: postgres
+volumes:
+  data: {}
+networks:
+  back:
+    driver: bridge
+`
+
+func mergeOverlayServices(t *testing.T) (map[string]*ServiceConfig, map[string]*VolumeConfig, map[string]*NetworkConfig) {
+	_, services, volumes, networks, err := Merge(NewServiceConfigs(), nil, &NullLookup{}, "", []byte(overlayServices), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return services, volumes, networks
+}
+
+func TestJSONPatchOverlay(t *testing.T) {
+	overlay, err := ParseOverlay("patch.yml", []byte(`
+- op: test
+  path: /services/web/image
+  value: nginx
+- op: remove
+  path: /services/web/ports/0
+- op: add
+  path: /services/web/ports/-
+  value: "8080:8080"
+- op: replace
+  path: /services/db/image
+  value: postgres:9.6
+- op: copy
+  from: /services/web/logging
+  path: /services/db/logging
+- op: move
+  from: /networks/back
+  path: /networks/backend
+- op: add
+  path: /volumes/cache
+  value: {}
+`))
+	assert.Nil(t, err)
+
+	services, volumes, networks := mergeOverlayServices(t)
+	services, volumes, networks, err = ApplyOverlays(services, volumes, networks, []*Overlay{overlay}, nil)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"443:443", "8080:8080"}, services["web"].Ports)
+	assert.Equal(t, "postgres:9.6", services["db"].Image)
+	assert.Equal(t, "syslog", services["db"].Logging.Driver)
+	assert.Equal(t, "data", services["web"].Volumes.Volumes[0].Source)
+	assert.Contains(t, volumes, "cache")
+	assert.Contains(t, volumes, "data")
+	assert.Contains(t, networks, "backend")
+	assert.NotContains(t, networks, "back")
+}
+
+func TestJSONPatchOverlayErrors(t *testing.T) {
+	services, volumes, networks := mergeOverlayServices(t)
+
+	for _, content := range []string{
+		"- {op: remove, path: /services/cache}",
+		"- {op: remove, path: /services/web/ports/2}",
+		"- {op: test, path: /services/web/image, value: redis}",
+		"- {op: add, path: services/web/image, value: redis}",
+	} {
+		overlay, err := ParseOverlay("patch.yml", []byte(content))
+		assert.Nil(t, err)
+		_, _, _, err = ApplyOverlays(services, volumes, networks, []*Overlay{overlay}, nil)
+		assert.NotNil(t, err, content)
+	}
+
+	_, err := ParseOverlay("patch.yml", []byte("- {op: append, path: /services}"))
+	assert.NotNil(t, err)
+}
+
+func TestStrategicMergeOverlay(t *testing.T) {
+	overlay, err := ParseOverlay("overlay.yml", []byte(`
+services:
+  web:
+    ports: ["8080:80"]
+    environment:
+      DEBUG: "true"
+    logging:
+      $patch: delete
+  db:
+    labels:
+      tier: backend
+  cache:
+    image: redis
+volumes:
+  data: null
+`))
+	assert.Nil(t, err)
+
+	services, volumes, networks := mergeOverlayServices(t)
+	services, volumes, _, err = ApplyOverlays(services, volumes, networks, []*Overlay{overlay}, nil)
+	assert.Nil(t, err)
+
+	web := services["web"]
+	assert.Equal(t, []string{"8080:80"}, web.Ports)
+	assert.Equal(t, map[string]string{"DEBUG": "true", "LEVEL": "info"}, web.Environment.ToMap())
+	assert.Equal(t, "", web.Logging.Driver)
+	assert.Equal(t, "backend", services["db"].Labels["tier"])
+	assert.Equal(t, "redis", services["cache"].Image)
+	assert.NotContains(t, volumes, "data")
+}
+
+func TestOverlayValidation(t *testing.T) {
+	overlay, err := ParseOverlay("overlay.yml", []byte(`
+services:
+  web:
+    portz: ["8080:80"]
+`))
+	assert.Nil(t, err)
+
+	services, volumes, networks := mergeOverlayServices(t)
+	_, _, _, err = ApplyOverlays(services, volumes, networks, []*Overlay{overlay}, nil)
+	assert.NotNil(t, err)
+
+	_, err = ParseOverlay("overlay.yml", []byte("web:\n  image: nginx\n"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,495 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/docker/libcompose/utils"
+	composeYaml "github.com/docker/libcompose/yaml"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Values from Via tell how a value reached a service without being set by
+// its own definition.
+const (
+	ViaExtends  = "extends"
+	ViaEnvFile  = "env_file"
+	ViaDefaults = "defaults"
+)
+
+// Origin locates where a value of a service was set.
+type Origin struct {
+	// File is the compose file, or the env_file, holding the value. Line
+	// and Column are 0 when the position is unknown.
+	File   string
+	Line   int
+	Column int
+	// Service is the service whose definition holds the value, it differs
+	// from the explained service when the value comes through extends.
+	Service string
+	// Via is empty when the service sets the value itself, otherwise it is
+	// ViaExtends, ViaEnvFile, ServiceDefaultsKey or ViaDefaults for
+	// ParseOptions.Defaults.
+	Via string
+	// Variables are the environment variables interpolated in the value.
+	Variables []string
+}
+
+func (o Origin) String() string {
+	location := o.File
+	if location == "" {
+		location = "<unknown>"
+	}
+	if o.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", location, o.Line, o.Column)
+	}
+	var details []string
+	if o.Via != "" {
+		details = append(details, "via "+o.Via)
+	}
+	for _, variable := range o.Variables {
+		details = append(details, "$"+variable)
+	}
+	if len(details) > 0 {
+		location = fmt.Sprintf("%s (%s)", location, strings.Join(details, ", "))
+	}
+	return location
+}
+
+// Explanation is a leaf value of a service with its origin.
+type Explanation struct {
+	Service string
+	// Key is the dotted path of the value, with items of lists numbered and
+	// KEY=VALUE lists such as environment keyed by their KEY.
+	Key    string
+	Value  string
+	Origin Origin
+}
+
+func (e Explanation) String() string {
+	origin := e.Origin.String()
+	if e.Origin.Service != "" && e.Origin.Service != e.Service {
+		origin = fmt.Sprintf("%s in service %s", origin, e.Origin.Service)
+	}
+	return fmt.Sprintf("%s.%s=%s\t%s", e.Service, e.Key, e.Value, origin)
+}
+
+// Provenance records the origin of every leaf value of the services merged
+// with the ParseOptions holding it.
+type Provenance struct {
+	mu       sync.RWMutex
+	services map[string]map[string]Explanation
+	source   *provenanceSource
+}
+
+// NewProvenance creates an empty Provenance.
+func NewProvenance() *Provenance {
+	return &Provenance{
+		services: map[string]map[string]Explanation{},
+	}
+}
+
+// Get returns the explanation of a single value of a service.
+func (p *Provenance) Get(service, key string) (Explanation, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	explanation, ok := p.services[service][key]
+	return explanation, ok
+}
+
+// Explain returns the explanations of the values of a service at or under
+// key, sorted by key. An empty key explains the whole service.
+func (p *Provenance) Explain(service, key string) []Explanation {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	explanations := []Explanation{}
+	for path, explanation := range p.services[service] {
+		if key == "" || path == key || strings.HasPrefix(path, key+".") {
+			explanations = append(explanations, explanation)
+		}
+	}
+	sort.Slice(explanations, func(i, j int) bool {
+		return lessPath(ParsePath(explanations[i].Key), ParsePath(explanations[j].Key))
+	})
+	return explanations
+}
+
+// lessPath orders paths by segment, numerically for list indexes.
+func lessPath(a, b Path) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		ai, aerr := strconv.Atoi(a[i])
+		bi, berr := strconv.Atoi(b[i])
+		if aerr == nil && berr == nil {
+			return ai < bi
+		}
+		return a[i] < b[i]
+	}
+	return len(a) < len(b)
+}
+
+// provenanceSource is the compose file being merged.
+type provenanceSource struct {
+	file           string
+	doc            *Document
+	v1             bool
+	resourceLookup ResourceLookup
+}
+
+func newProvenanceSource(file string, content []byte, resourceLookup ResourceLookup) (*provenanceSource, error) {
+	doc, err := ParseDocument(content)
+	if err != nil {
+		return nil, err
+	}
+	var version string
+	if _, err := doc.Get(Path{"version"}, &version); err != nil {
+		return nil, err
+	}
+	major, err := getComposeMajorVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	return &provenanceSource{
+		file:           file,
+		doc:            doc,
+		v1:             major < 2,
+		resourceLookup: resourceLookup,
+	}, nil
+}
+
+func (s *provenanceSource) servicePath(service string) Path {
+	if s.v1 {
+		return Path{service}
+	}
+	return Path{"services", service}
+}
+
+// begin sets the compose file the next recorded values come from.
+func (p *Provenance) begin(file string, content []byte, resourceLookup ResourceLookup) error {
+	source, err := newProvenanceSource(file, content, resourceLookup)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.source = source
+	p.mu.Unlock()
+	return nil
+}
+
+// record records the values a compose file contributes to a service, before
+// they are merged into the existing raw service, if any, mirroring the
+// merge to number the items appended to lists.
+func (p *Provenance) record(service string, data, existing RawService, defaults RawService) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.source == nil {
+		return
+	}
+
+	values, ok := p.services[service]
+	if !ok || existing == nil {
+		values = map[string]Explanation{}
+		p.services[service] = values
+	}
+
+	for key, value := range data {
+		if p.source.v1 && key == "image" {
+			dropPath(values, "build")
+			dropPath(values, "dockerfile")
+		} else if p.source.v1 && key == "build" {
+			dropPath(values, "image")
+		}
+
+		offset := 0
+		switch existingValue := existing[key].(type) {
+		case []interface{}:
+			if _, isList := value.([]interface{}); isList && !keyedLists[key] {
+				offset = len(existingValue)
+			} else if !isList {
+				dropPath(values, key)
+			}
+		case map[interface{}]interface{}:
+			if valueMap, isMap := value.(map[interface{}]interface{}); isMap {
+				for subKey := range valueMap {
+					dropPath(values, key+"."+fmt.Sprint(subKey))
+				}
+			} else {
+				dropPath(values, key)
+			}
+		default:
+			dropPath(values, key)
+		}
+
+		leaves := map[string]leaf{}
+		collectLeaves(Path{key}, value, leaves)
+		for _, leaf := range leaves {
+			origin := p.locate(service, leaf.path, defaults)
+			path := leaf.path
+			if offset > 0 {
+				if index, err := strconv.Atoi(path[1]); err == nil {
+					path[1] = strconv.Itoa(index + offset)
+				}
+			}
+			values[path.String()] = Explanation{Service: service, Key: path.String(), Value: leaf.value, Origin: origin}
+		}
+	}
+}
+
+func dropPath(values map[string]Explanation, key string) {
+	for path := range values {
+		if path == key || strings.HasPrefix(path, key+".") {
+			delete(values, path)
+		}
+	}
+}
+
+// keyedLists are the lists of KEY=VALUE whose items are keyed rather than
+// numbered.
+var keyedLists = map[string]bool{
+	"environment": true,
+	"labels":      true,
+	"args":        true,
+}
+
+type leaf struct {
+	path  Path
+	value string
+}
+
+func collectLeaves(path Path, value interface{}, leaves map[string]leaf) {
+	child := func(key string) Path {
+		return append(path[:len(path):len(path)], key)
+	}
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for key, item := range v {
+			collectLeaves(child(fmt.Sprint(key)), item, leaves)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			collectLeaves(child(key), item, leaves)
+		}
+	case []interface{}:
+		for i, item := range v {
+			if s, ok := item.(string); ok && keyedLists[path[len(path)-1]] {
+				parts := append(strings.SplitN(s, "=", 2), "")
+				keyPath := child(parts[0])
+				leaves[keyPath.String()] = leaf{path: keyPath, value: parts[1]}
+				continue
+			}
+			collectLeaves(child(strconv.Itoa(i)), item, leaves)
+		}
+	case nil:
+		leaves[path.String()] = leaf{path: path, value: ""}
+	default:
+		// Typed slices, such as the environment merged with env_file
+		if reflected := reflect.ValueOf(v); reflected.Kind() == reflect.Slice {
+			items := make([]interface{}, reflected.Len())
+			for i := range items {
+				items[i] = reflected.Index(i).Interface()
+			}
+			collectLeaves(path, items, leaves)
+			return
+		}
+		leaves[path.String()] = leaf{path: path, value: fmt.Sprint(v)}
+	}
+}
+
+func (p *Provenance) locate(service string, path Path, defaults RawService) Origin {
+	if origin, ok := p.source.locate(service, path, 0); ok {
+		return origin
+	}
+	if position, value := findNodes(p.source.doc, append(Path{ServiceDefaultsKey}, path...)); position != nil {
+		return Origin{File: p.source.file, Line: position.Line, Column: position.Column, Via: ServiceDefaultsKey, Variables: interpolatedVariables(value)}
+	}
+	if _, ok := defaults[path[0]]; ok {
+		return Origin{Via: ViaDefaults}
+	}
+	return Origin{File: p.source.file, Service: service}
+}
+
+// maxExtendsDepth stops locating values through extends loops.
+const maxExtendsDepth = 10
+
+// locate finds the node setting a value of a service, in the service
+// itself, in its env_file or in the services it extends.
+func (s *provenanceSource) locate(service string, path Path, depth int) (Origin, bool) {
+	servicePath := s.servicePath(service)
+	if position, value := findNodes(s.doc, append(servicePath, path...)); position != nil {
+		return Origin{File: s.file, Line: position.Line, Column: position.Column, Service: service, Variables: interpolatedVariables(value)}, true
+	}
+	if depth > maxExtendsDepth || s.resourceLookup == nil {
+		return Origin{}, false
+	}
+
+	if len(path) == 2 && path[0] == "environment" {
+		var envFile interface{}
+		var envFiles composeYaml.Stringorslice
+		if found, err := s.doc.Get(append(servicePath, "env_file"), &envFile); err == nil && found {
+			utils.Convert(envFile, &envFiles)
+		}
+		// The last env_file wins
+		for i := len(envFiles) - 1; i >= 0; i-- {
+			content, resolved, err := s.resourceLookup.Lookup(envFiles[i], s.file)
+			if err != nil {
+				continue
+			}
+			if line := envFileLine(content, path[1]); line > 0 {
+				return Origin{File: resolved, Line: line, Column: 1, Service: service, Via: ViaEnvFile}, true
+			}
+		}
+	}
+
+	var extends interface{}
+	if found, err := s.doc.Get(append(servicePath, "extends"), &extends); err != nil || !found {
+		return Origin{}, false
+	}
+	var baseService, baseFile string
+	switch e := extends.(type) {
+	case string:
+		baseService = e
+	case map[string]interface{}:
+		baseService = asString(e["service"])
+		baseFile = asString(e["file"])
+	}
+	if baseService == "" {
+		return Origin{}, false
+	}
+
+	base := s
+	if baseFile != "" {
+		content, resolved, err := s.resourceLookup.Lookup(baseFile, s.file)
+		if err != nil {
+			return Origin{}, false
+		}
+		if base, err = newProvenanceSource(resolved, content, s.resourceLookup); err != nil {
+			return Origin{}, false
+		}
+	}
+	origin, ok := base.locate(baseService, path, depth+1)
+	if ok && origin.Via == "" {
+		origin.Via = ViaExtends
+	}
+	return origin, ok
+}
+
+// findNodes returns the node locating the value at path, the key of mapping
+// entries and the item of lists, and the node of the value. Lists of
+// KEY=VALUE are searched by KEY, and a scalar found before the end of the path
+// holds the whole value, such as "build: ." for build.context.
+func findNodes(doc *Document, path Path) (*yamlv3.Node, *yamlv3.Node) {
+	if doc.root == nil || len(path) == 0 {
+		return nil, nil
+	}
+	node := doc.root.Content[0]
+	var position *yamlv3.Node
+	for _, key := range path {
+		node = resolveAlias(node)
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			keyNode, valueNode := mappingEntry(node, key)
+			if keyNode == nil {
+				return nil, nil
+			}
+			position, node = keyNode, valueNode
+		case yamlv3.SequenceNode:
+			item := sequenceItem(node, key)
+			if item == nil {
+				return nil, nil
+			}
+			position, node = item, item
+		case yamlv3.ScalarNode:
+			if position == nil {
+				return nil, nil
+			}
+			return position, node
+		default:
+			return nil, nil
+		}
+	}
+	return position, resolveAlias(node)
+}
+
+// mappingEntry returns the key and value nodes of a mapping entry, looking
+// into merge keys if the mapping doesn't hold the key itself.
+func mappingEntry(node *yamlv3.Node, key string) (*yamlv3.Node, *yamlv3.Node) {
+	if i := mappingIndex(node, key); i >= 0 {
+		return node.Content[i], node.Content[i+1]
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Tag != "!!merge" {
+			continue
+		}
+		merged := resolveAlias(node.Content[i+1])
+		sources := []*yamlv3.Node{merged}
+		if merged.Kind == yamlv3.SequenceNode {
+			sources = merged.Content
+		}
+		for _, source := range sources {
+			if source = resolveAlias(source); source.Kind != yamlv3.MappingNode {
+				continue
+			}
+			if keyNode, valueNode := mappingEntry(source, key); keyNode != nil {
+				return keyNode, valueNode
+			}
+		}
+	}
+	return nil, nil
+}
+
+// sequenceItem returns an item of a sequence by index, or the last KEY or
+// KEY=VALUE item for a key.
+func sequenceItem(node *yamlv3.Node, key string) *yamlv3.Node {
+	if index, err := strconv.Atoi(key); err == nil {
+		if index < 0 || index >= len(node.Content) {
+			return nil
+		}
+		return node.Content[index]
+	}
+	var found *yamlv3.Node
+	for _, item := range node.Content {
+		item = resolveAlias(item)
+		if item.Kind == yamlv3.ScalarNode && (item.Value == key || strings.HasPrefix(item.Value, key+"=")) {
+			found = item
+		}
+	}
+	return found
+}
+
+var variableRegexp = regexp.MustCompile(`\$\$|\$\{?([a-zA-Z_][a-zA-Z0-9_]*)`)
+
+// interpolatedVariables returns the variables referenced by a scalar.
+func interpolatedVariables(node *yamlv3.Node) []string {
+	if node == nil || node.Kind != yamlv3.ScalarNode {
+		return nil
+	}
+	var variables []string
+	for _, match := range variableRegexp.FindAllStringSubmatch(node.Value, -1) {
+		if match[1] != "" && !utils.Contains(variables, match[1]) {
+			variables = append(variables, match[1])
+		}
+	}
+	return variables
+}
+
+func envFileLine(content []byte, key string) int {
+	scanner := bufio.NewScanner(bytes.NewBuffer(content))
+	line, found := 0, 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == key || strings.HasPrefix(text, key+"=") {
+			found = line
+		}
+	}
+	return found
+}
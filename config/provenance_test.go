@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mapLookup map[string]string
+
+func (m mapLookup) Lookup(file, relativeTo string) ([]byte, string, error) {
+	content, ok := m[file]
+	if !ok {
+		return nil, "", fmt.Errorf("%s not found", file)
+	}
+	return []byte(content), file, nil
+}
+
+func (m mapLookup) ResolvePath(path, inFile string) string {
+	return path
+}
+
+func TestProvenance(t *testing.T) {
+	lookup := mapLookup{"web.env": "# web\nWORKERS=4\nDEBUG=false\n"}
+	environment := MockEnvironmentLookup{map[string]string{"TAG": "1.11"}}
+	options := &ParseOptions{
+		Interpolate: true,
+		Validate:    true,
+		Provenance:  NewProvenance(),
+	}
+
+	configs := NewServiceConfigs()
+	for _, file := range []struct {
+		name    string
+		content string
+	}{
+		{"docker-compose.yml", `version: '2'
+x-service-defaults:
+  restart: always
+services:
+  base:
+    image: nginx:${TAG}
+    ports:
+      - "80:80"
+  web:
+    extends: base
+    env_file: web.env
+    environment:
+      LEVEL: info
+    labels:
+      com.example.tier: front
+`},
+		{"docker-compose.override.yml", `version: '2'
+services:
+  web:
+    environment:
+      - DEBUG=true
+    ports:
+      - "8080:8080"
+`},
+	} {
+		_, services, _, _, err := Merge(configs, environment, lookup, file.name, []byte(file.content), options)
+		assert.Nil(t, err)
+		for name, service := range services {
+			configs.Add(name, service)
+		}
+	}
+
+	provenance := options.Provenance
+	expectations := map[string]string{
+		"image":                   "web.image=nginx:1.11\tdocker-compose.yml:6:5 (via extends, $TAG) in service base",
+		"ports.0":                 "web.ports.0=80:80\tdocker-compose.yml:8:9 (via extends) in service base",
+		"ports.1":                 "web.ports.1=8080:8080\tdocker-compose.override.yml:7:9",
+		"environment.LEVEL":       "web.environment.LEVEL=info\tdocker-compose.yml:13:7",
+		"environment.WORKERS":     "web.environment.WORKERS=4\tweb.env:2:1 (via env_file)",
+		"environment.DEBUG":       "web.environment.DEBUG=true\tdocker-compose.override.yml:5:9",
+		"labels.com.example.tier": "web.labels.com.example.tier=front\tdocker-compose.yml:15:7",
+		"restart":                 "web.restart=always\tdocker-compose.yml:3:3 (via x-service-defaults)",
+	}
+	for key, expected := range expectations {
+		explanation, ok := provenance.Get("web", key)
+		assert.True(t, ok, key)
+		assert.Equal(t, expected, explanation.String())
+	}
+
+	environmentValues := provenance.Explain("web", "environment")
+	assert.Equal(t, 3, len(environmentValues))
+	assert.Equal(t, "environment.DEBUG", environmentValues[0].Key)
+
+	assert.Equal(t, 0, len(provenance.Explain("web", "links")))
+	assert.True(t, len(provenance.Explain("web", "")) >= len(expectations))
+}
+
+func TestProvenanceV1(t *testing.T) {
+	options := &ParseOptions{
+		Interpolate: true,
+		Validate:    true,
+		Provenance:  NewProvenance(),
+	}
+	configs := NewServiceConfigs()
+
+	_, services, _, _, err := Merge(configs, nil, &NullLookup{}, "docker-compose.yml", []byte(`
+web:
+  image: nginx
+db: &db
+  image: postgres
+db2:
+  <<: *db
+  command: run
+`), options)
+	assert.Nil(t, err)
+	for name, service := range services {
+		configs.Add(name, service)
+	}
+
+	_, _, _, _, err = Merge(configs, nil, &NullLookup{}, "override.yml", []byte(`
+web:
+  build: .
+`), options)
+	assert.Nil(t, err)
+
+	explanation, ok := options.Provenance.Get("db2", "image")
+	assert.True(t, ok)
+	assert.Equal(t, "docker-compose.yml:5:3", explanation.Origin.String())
+
+	_, ok = options.Provenance.Get("web", "image")
+	assert.False(t, ok, "build replaces image in version 1")
+	explanation, ok = options.Provenance.Get("web", "build")
+	assert.True(t, ok)
+	assert.Equal(t, "override.yml", explanation.Origin.File)
+}
@@ -286,6 +286,7 @@ var servicesSchemaDataV2 = `{
         "external_links": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
         "extra_hosts": {"$ref": "#/definitions/list_or_dict"},
         "group_add": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
+        "healthcheck": {"$ref": "#/definitions/healthcheck"},
         "hostname": {"type": "string"},
         "image": {"type": "string"},
         "ipc": {"type": "string"},
@@ -379,7 +380,8 @@ var servicesSchemaDataV2 = `{
         "volumes": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
         "volume_driver": {"type": "string"},
         "volumes_from": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
-        "working_dir": {"type": "string"}
+        "working_dir": {"type": "string"},
+        "x-update-config": {"$ref": "#/definitions/update_config"}
       },
 
       "dependencies": {
@@ -388,6 +390,37 @@ var servicesSchemaDataV2 = `{
       "additionalProperties": false
     },
 
+    "healthcheck": {
+      "id": "#/definitions/healthcheck",
+      "type": "object",
+      "properties": {
+        "disable": {"type": "boolean"},
+        "interval": {"type": "string"},
+        "retries": {"type": "number"},
+        "test": {
+          "oneOf": [
+            {"type": "string"},
+            {"type": "array", "items": {"type": "string"}}
+          ]
+        },
+        "timeout": {"type": "string"}
+      },
+      "additionalProperties": false
+    },
+
+    "update_config": {
+      "id": "#/definitions/update_config",
+      "type": "object",
+      "properties": {
+        "delay": {"type": "string"},
+        "failure_action": {"type": "string", "enum": ["continue", "pause"]},
+        "monitor": {"type": "string"},
+        "order": {"type": "string", "enum": ["start-first", "stop-first"]},
+        "parallelism": {"type": "integer", "minimum": 0}
+      },
+      "additionalProperties": false
+    },
+
     "network": {
       "id": "#/definitions/network",
       "type": "object",
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SensitiveKey is the top-level key listing the environment variables whose
+// values are sensitive.
+const SensitiveKey = "x-sensitive"
+
+// Mask is printed instead of sensitive values.
+const Mask = "********"
+
+// minRedactedLength is the length under which values aren't masked in free
+// text, as they can't be told apart from the rest of it.
+const minRedactedLength = 6
+
+// sensitiveNamePattern matches the names of the environment variables that
+// usually hold secrets.
+var sensitiveNamePattern = regexp.MustCompile(`(?i)(passw(or)?d|secret|token|api_?key|private_?key|credential|auth)`)
+
+// Sensitive records the values to mask when printing configurations, events
+// and logs: the values of the environment variables whose name looks like a
+// secret or is listed under x-sensitive, and the values read from env files.
+type Sensitive struct {
+	mu     sync.RWMutex
+	names  map[string]bool
+	values map[string]bool
+}
+
+// NewSensitive creates an empty Sensitive, to set in ParseOptions.
+func NewSensitive() *Sensitive {
+	return &Sensitive{
+		names:  map[string]bool{},
+		values: map[string]bool{},
+	}
+}
+
+func (s *Sensitive) addNames(names []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, name := range names {
+		s.names[name] = true
+	}
+}
+
+func (s *Sensitive) addValue(value string) {
+	if value == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[value] = true
+}
+
+// IsSensitive checks if the value of an environment variable is sensitive.
+func (s *Sensitive) IsSensitive(name, value string) bool {
+	if sensitiveNamePattern.MatchString(name) {
+		return true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.names[name] || s.values[value]
+}
+
+// Collect records the values of the sensitive environment variables of the
+// services, for Redact to mask them.
+func (s *Sensitive) Collect(services map[string]*ServiceConfig) {
+	for _, service := range services {
+		for _, variable := range service.Environment {
+			parts := strings.SplitN(variable, "=", 2)
+			if len(parts) == 2 && s.IsSensitive(parts[0], parts[1]) {
+				s.addValue(parts[1])
+			}
+		}
+	}
+}
+
+// RedactServices returns copies of the services with the values of their
+// sensitive environment variables masked.
+func (s *Sensitive) RedactServices(services map[string]*ServiceConfig) map[string]*ServiceConfig {
+	redacted := make(map[string]*ServiceConfig, len(services))
+	for name, service := range services {
+		copied := *service
+		copied.Environment = nil
+		for _, variable := range service.Environment {
+			parts := strings.SplitN(variable, "=", 2)
+			if len(parts) == 2 && s.IsSensitive(parts[0], parts[1]) {
+				variable = parts[0] + "=" + Mask
+			}
+			copied.Environment = append(copied.Environment, variable)
+		}
+		redacted[name] = &copied
+	}
+	return redacted
+}
+
+// Redact masks the sensitive values found in text. Values shorter than a few
+// characters are left as is.
+func (s *Sensitive) Redact(text string) string {
+	s.mu.RLock()
+	var values []string
+	for value := range s.values {
+		if len(value) >= minRedactedLength {
+			values = append(values, value)
+		}
+	}
+	s.mu.RUnlock()
+	if len(values) == 0 {
+		return text
+	}
+
+	// Longer values first, so that a value containing another is masked
+	// as a whole
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+	replacements := make([]string, 0, 2*len(values))
+	for _, value := range values {
+		replacements = append(replacements, value, Mask)
+	}
+	return strings.NewReplacer(replacements...).Replace(text)
+}
+
+// Hook returns a logrus hook masking the sensitive values in the messages
+// and the string fields of log entries.
+func (s *Sensitive) Hook() logrus.Hook {
+	return &sensitiveHook{s}
+}
+
+type sensitiveHook struct {
+	sensitive *Sensitive
+}
+
+func (h *sensitiveHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *sensitiveHook) Fire(entry *logrus.Entry) error {
+	entry.Message = h.sensitive.Redact(entry.Message)
+	if len(entry.Data) > 0 {
+		// The fields may be shared with other entries
+		data := make(logrus.Fields, len(entry.Data))
+		for key, value := range entry.Data {
+			if text, ok := value.(string); ok {
+				value = h.sensitive.Redact(text)
+			}
+			data[key] = value
+		}
+		entry.Data = data
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func mergeSensitive(t *testing.T, content string) (*Sensitive, map[string]*ServiceConfig) {
+	sensitive := NewSensitive()
+	options := &ParseOptions{
+		Interpolate: true,
+		Validate:    true,
+		Sensitive:   sensitive,
+	}
+	lookup := mapLookup{"web.env": "SESSION_KEY=s3cr3t-session\nWORKERS=4\n"}
+	_, services, _, _, err := Merge(NewServiceConfigs(), nil, lookup, "docker-compose.yml", []byte(content), options)
+	assert.Nil(t, err)
+	sensitive.Collect(services)
+	return sensitive, services
+}
+
+func TestSensitive(t *testing.T) {
+	for _, content := range []string{`version: '2'
+x-sensitive:
+  - LICENSE
+services:
+  web:
+    image: nginx
+    env_file: web.env
+    environment:
+      DB_PASSWORD: hunter2-long
+      LICENSE: abc-license
+      LEVEL: info
+`, `x-sensitive:
+  - LICENSE
+web:
+  image: nginx
+  env_file: web.env
+  environment:
+    DB_PASSWORD: hunter2-long
+    LICENSE: abc-license
+    LEVEL: info
+`} {
+		sensitive, services := mergeSensitive(t, content)
+		assert.Equal(t, 1, len(services))
+
+		assert.True(t, sensitive.IsSensitive("DB_PASSWORD", "anything"))
+		assert.True(t, sensitive.IsSensitive("api_key", "anything"))
+		assert.True(t, sensitive.IsSensitive("LICENSE", "abc-license"))
+		assert.True(t, sensitive.IsSensitive("SESSION_KEY", "s3cr3t-session"))
+		assert.True(t, sensitive.IsSensitive("WORKERS", "4"), "values read from env files are sensitive")
+		assert.False(t, sensitive.IsSensitive("LEVEL", "info"))
+
+		redacted := sensitive.RedactServices(services)
+		assert.Equal(t, []string{"DB_PASSWORD=" + Mask, "LEVEL=info", "LICENSE=" + Mask, "SESSION_KEY=" + Mask, "WORKERS=" + Mask}, []string(redacted["web"].Environment))
+		assert.Contains(t, []string(services["web"].Environment), "DB_PASSWORD=hunter2-long", "services are copied")
+
+		assert.Equal(t, "login with "+Mask+" and "+Mask+", 4 workers", sensitive.Redact("login with hunter2-long and abc-license, 4 workers"))
+	}
+}
+
+func TestSensitiveRedactLongestFirst(t *testing.T) {
+	sensitive := NewSensitive()
+	sensitive.addValue("secret")
+	sensitive.addValue("secret-extended")
+	assert.Equal(t, "a "+Mask+" b "+Mask, sensitive.Redact("a secret-extended b secret"))
+	assert.Equal(t, "unchanged", NewSensitive().Redact("unchanged"))
+}
+
+func TestSensitiveHook(t *testing.T) {
+	sensitive := NewSensitive()
+	sensitive.addValue("hunter2-long")
+
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.Out = &buf
+	logger.Formatter = &logrus.TextFormatter{DisableColors: true, DisableTimestamp: true}
+	logger.AddHook(sensitive.Hook())
+
+	fields := logrus.Fields{"env": "DB_PASSWORD=hunter2-long", "count": 2}
+	logger.WithFields(fields).Info("Creating with hunter2-long")
+	assert.False(t, strings.Contains(buf.String(), "hunter2-long"), buf.String())
+	assert.Contains(t, buf.String(), "Creating with "+Mask)
+	assert.Equal(t, "DB_PASSWORD=hunter2-long", fields["env"], "fields are copied")
+}
+
+func TestInterpolationErrorHidesEnvironmentValues(t *testing.T) {
+	for _, content := range []string{`version: '2'
+services:
+  web:
+    image: nginx
+    environment:
+      TOKEN: "abc${"
+`, `version: '2'
+services:
+  web:
+    image: nginx
+    environment:
+      - TOKEN=abc${
+`} {
+		_, _, _, _, err := Merge(NewServiceConfigs(), nil, &NullLookup{}, "docker-compose.yml", []byte(content), nil)
+		assert.NotNil(t, err)
+		assert.Equal(t, `Invalid interpolation format for environment variable "TOKEN"`, err.Error())
+	}
+}
+
+func TestConvertToV2MovesSensitive(t *testing.T) {
+	converted, _, err := ConvertToV2([]byte("web:\n  image: nginx\nx-sensitive:\n  - TOKEN\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, "version: \"2\"\nservices:\n  web:\n    image: nginx\nx-sensitive:\n  - TOKEN\n", string(converted))
+}
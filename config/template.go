@@ -0,0 +1,185 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// TemplateError is an error rendering a compose file template, located in
+// the source file.
+type TemplateError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+	Source  string
+}
+
+func (e *TemplateError) Error() string {
+	location := e.File
+	if location == "" {
+		location = "<template>"
+	}
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, e.Line)
+		if e.Column > 0 {
+			location = fmt.Sprintf("%s:%d", location, e.Column)
+		}
+	}
+	message := fmt.Sprintf("%s: %s", location, e.Message)
+	if e.Source != "" {
+		message = fmt.Sprintf("%s\n    %s", message, e.Source)
+	}
+	return message
+}
+
+// The name given to the templates, errors of text/template are prefixed with
+// it and the location of the failure.
+const templateName = "compose"
+
+var templateErrorRegexp = regexp.MustCompile(`^template: ` + templateName + `:(\d+)(?::(\d+))?: (?:executing "` + templateName + `" at <[^>]*>: )?(.*)$`)
+
+// ParseValues reads the given YAML values files, the values of later files
+// are merged over the values of earlier ones.
+func ParseValues(contents ...[]byte) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, content := range contents {
+		var fileValues map[interface{}]interface{}
+		if err := yaml.Unmarshal(content, &fileValues); err != nil {
+			return nil, err
+		}
+		mergeValues(values, stringKeys(fileValues).(map[string]interface{}))
+	}
+	return values, nil
+}
+
+// stringKeys converts the keys of the YAML maps of value to strings, so that
+// templates can address them as fields.
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := map[string]interface{}{}
+		for key, item := range v {
+			result[fmt.Sprint(key)] = stringKeys(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = stringKeys(item)
+		}
+		return result
+	}
+	return value
+}
+
+func mergeValues(base, override map[string]interface{}) {
+	for key, value := range override {
+		baseMap, baseIsMap := base[key].(map[string]interface{})
+		overrideMap, overrideIsMap := value.(map[string]interface{})
+		if baseIsMap && overrideIsMap {
+			mergeValues(baseMap, overrideMap)
+			continue
+		}
+		base[key] = value
+	}
+}
+
+// RenderTemplate renders a compose file as a text/template, with the values
+// as data. Besides the text/template builtins, the default, required, toYaml
+// and indent functions are available. The file is only used to locate errors.
+func RenderTemplate(file string, content []byte, values map[string]interface{}) ([]byte, error) {
+	tmpl, err := template.New(templateName).Funcs(templateFuncs).Parse(string(content))
+	if err != nil {
+		return nil, templateError(file, content, err)
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, values); err != nil {
+		return nil, templateError(file, content, err)
+	}
+	return buffer.Bytes(), nil
+}
+
+func templateError(file string, content []byte, err error) error {
+	matches := templateErrorRegexp.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return &TemplateError{File: file, Message: err.Error()}
+	}
+
+	templateErr := &TemplateError{File: file, Message: matches[3]}
+	templateErr.Line, _ = strconv.Atoi(matches[1])
+	if matches[2] != "" {
+		templateErr.Column, _ = strconv.Atoi(matches[2])
+	}
+	templateErr.Message = strings.TrimPrefix(templateErr.Message, "error calling required: ")
+
+	lines := strings.Split(string(content), "\n")
+	if templateErr.Line > 0 && templateErr.Line <= len(lines) {
+		templateErr.Source = strings.TrimSpace(lines[templateErr.Line-1])
+	}
+	return templateErr
+}
+
+var templateFuncs = template.FuncMap{
+	"default":  templateDefault,
+	"required": templateRequired,
+	"toYaml":   templateToYaml,
+	"indent":   templateIndent,
+}
+
+// isEmptyValue reports whether a template value is unset, the zero value of
+// its type or an empty collection.
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return reflect.DeepEqual(value, reflect.Zero(v.Type()).Interface())
+}
+
+// templateDefault returns value, or def if value is empty. The value comes
+// last so that it can be piped: {{ .tag | default "latest" }}.
+func templateDefault(def interface{}, value ...interface{}) interface{} {
+	if len(value) == 0 || isEmptyValue(value[0]) {
+		return def
+	}
+	return value[0]
+}
+
+// templateRequired fails the rendering with message if value is empty.
+func templateRequired(message string, value interface{}) (interface{}, error) {
+	if isEmptyValue(value) {
+		return nil, errors.New(message)
+	}
+	return value, nil
+}
+
+// templateToYaml marshals value, without the trailing newline.
+func templateToYaml(value interface{}) (string, error) {
+	bytes, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(bytes), "\n"), nil
+}
+
+// templateIndent prefixes every line of text with spaces spaces.
+func templateIndent(spaces int, text string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.Replace(text, "\n", "\n"+pad, -1)
+}
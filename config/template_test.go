@@ -0,0 +1,77 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseValues(t *testing.T) {
+	values, err := ParseValues([]byte(`
+image:
+  name: nginx
+  tag: "1.10"
+ports:
+  80: 8080
+`), []byte(`
+image:
+  tag: "1.11"
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"image": map[string]interface{}{"name": "nginx", "tag": "1.11"},
+		"ports": map[string]interface{}{"80": 8080},
+	}, values)
+}
+
+func TestRenderTemplate(t *testing.T) {
+	values, err := ParseValues([]byte(`
+image:
+  name: nginx
+environment:
+  DEBUG: "true"
+  LEVEL: info
+`))
+	assert.Nil(t, err)
+
+	rendered, err := RenderTemplate("docker-compose.yml", []byte(`version: "2"
+services:
+  web:
+    image: {{ required "image.name is required" .image.name }}:{{ .image.tag | default "latest" }}
+    environment:
+{{ toYaml .environment | indent 6 }}
+`), values)
+	assert.Nil(t, err)
+	assert.Equal(t, `version: "2"
+services:
+  web:
+    image: nginx:latest
+    environment:
+      DEBUG: "true"
+      LEVEL: info
+`, string(rendered))
+
+	config, err := CreateConfig(rendered)
+	assert.Nil(t, err)
+	assert.Equal(t, "nginx:latest", config.Services["web"]["image"])
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	content := []byte(`version: "2"
+services:
+  web:
+    image: {{ required "image.name is required" .image }}
+`)
+	_, err := RenderTemplate("docker-compose.yml", content, map[string]interface{}{})
+	templateErr, ok := err.(*TemplateError)
+	assert.True(t, ok, "unexpected error %v", err)
+	assert.Equal(t, 4, templateErr.Line)
+	assert.Equal(t, "image.name is required", templateErr.Message)
+	assert.Equal(t, `image: {{ required "image.name is required" .image }}`, templateErr.Source)
+	assert.Contains(t, err.Error(), "docker-compose.yml:4:")
+
+	_, err = RenderTemplate("docker-compose.yml", []byte("services:\n  web: {{ .image | unknown }}\n"), nil)
+	templateErr, ok = err.(*TemplateError)
+	assert.True(t, ok, "unexpected error %v", err)
+	assert.Equal(t, 2, templateErr.Line)
+}
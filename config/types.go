@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/docker/libcompose/yaml"
 )
@@ -83,6 +86,96 @@ type Log struct {
 	Options map[string]string `yaml:"options,omitempty"`
 }
 
+// HealthCheck holds v2 healthcheck configuration
+type HealthCheck struct {
+	Test     yaml.Stringorslice `yaml:"test,omitempty"`
+	Interval string             `yaml:"interval,omitempty"`
+	Timeout  string             `yaml:"timeout,omitempty"`
+	Retries  int                `yaml:"retries,omitempty"`
+	Disable  bool               `yaml:"disable,omitempty"`
+}
+
+// TestCommand returns the healthcheck test in its engine form, starting with
+// CMD, CMD-SHELL or NONE. A test given as a single string is run by the shell.
+func (h HealthCheck) TestCommand() []string {
+	if h.Disable {
+		return []string{"NONE"}
+	}
+	if len(h.Test) == 0 {
+		return nil
+	}
+	switch h.Test[0] {
+	case "CMD", "CMD-SHELL", "NONE":
+		return []string(h.Test)
+	}
+	return []string{"CMD-SHELL", strings.Join(h.Test, " ")}
+}
+
+// Definitions of the update orders and failure actions of UpdateConfig.
+const (
+	UpdateOrderStopFirst  = "stop-first"
+	UpdateOrderStartFirst = "start-first"
+
+	UpdateFailurePause    = "pause"
+	UpdateFailureContinue = "continue"
+)
+
+// UpdateConfig holds the x-update-config extension of a v2 service, how up
+// recreates its containers.
+type UpdateConfig struct {
+	// Parallelism is the number of containers recreated at once, all of
+	// them if zero.
+	Parallelism int `yaml:"parallelism,omitempty"`
+	// Delay is the time waited between two batches, like "10s".
+	Delay string `yaml:"delay,omitempty"`
+	// Order is stop-first, the default, or start-first to start the new
+	// container before removing the previous one.
+	Order string `yaml:"order,omitempty"`
+	// FailureAction is pause, the default, to stop the update when a
+	// container fails, or continue.
+	FailureAction string `yaml:"failure_action,omitempty"`
+	// Monitor is how long a new container has to be ready before it is
+	// failed, like "30s", DefaultUpdateMonitor if not set.
+	Monitor string `yaml:"monitor,omitempty"`
+}
+
+// DefaultUpdateMonitor is the time a new container has to be ready when the
+// x-update-config of its service doesn't set a monitor.
+const DefaultUpdateMonitor = time.Minute
+
+// IsZero returns whether the update config isn't set, the containers are
+// then all recreated at once.
+func (u UpdateConfig) IsZero() bool {
+	return u == UpdateConfig{}
+}
+
+// DelayDuration returns Delay parsed, zero if not set.
+func (u UpdateConfig) DelayDuration() (time.Duration, error) {
+	if u.Delay == "" {
+		return 0, nil
+	}
+	delay, err := time.ParseDuration(u.Delay)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid x-update-config delay %q: %v", u.Delay, err)
+	}
+	return delay, nil
+}
+
+// MonitorDuration returns Monitor parsed, DefaultUpdateMonitor if not set.
+func (u UpdateConfig) MonitorDuration() (time.Duration, error) {
+	if u.Monitor == "" {
+		return DefaultUpdateMonitor, nil
+	}
+	monitor, err := time.ParseDuration(u.Monitor)
+	if err == nil && monitor <= 0 {
+		err = fmt.Errorf("not a positive duration")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Invalid x-update-config monitor %q: %v", u.Monitor, err)
+	}
+	return monitor, nil
+}
+
 // ServiceConfig holds version 2 of libcompose service configuration
 type ServiceConfig struct {
 	Build           yaml.Build           `yaml:"build,omitempty"`
@@ -108,6 +201,7 @@ type ServiceConfig struct {
 	ExternalLinks   []string             `yaml:"external_links,omitempty"`
 	ExtraHosts      []string             `yaml:"extra_hosts,omitempty"`
 	GroupAdd        []string             `yaml:"group_add,omitempty"`
+	HealthCheck     HealthCheck          `yaml:"healthcheck,omitempty"`
 	Image           string               `yaml:"image,omitempty"`
 	Isolation       string               `yaml:"isolation,omitempty"`
 	Hostname        string               `yaml:"hostname,omitempty"`
@@ -143,6 +237,7 @@ type ServiceConfig struct {
 	User            string               `yaml:"user,omitempty"`
 	WorkingDir      string               `yaml:"working_dir,omitempty"`
 	Ulimits         yaml.Ulimits         `yaml:"ulimits,omitempty"`
+	UpdateConfig    UpdateConfig         `yaml:"x-update-config,omitempty"`
 }
 
 // VolumeConfig holds v2 volume configuration
@@ -176,10 +271,12 @@ type NetworkConfig struct {
 
 // Config holds libcompose top level configuration
 type Config struct {
-	Version  string                 `yaml:"version,omitempty"`
-	Services RawServiceMap          `yaml:"services,omitempty"`
-	Volumes  map[string]interface{} `yaml:"volumes,omitempty"`
-	Networks map[string]interface{} `yaml:"networks,omitempty"`
+	Version         string                 `yaml:"version,omitempty"`
+	Services        RawServiceMap          `yaml:"services,omitempty"`
+	Volumes         map[string]interface{} `yaml:"volumes,omitempty"`
+	Networks        map[string]interface{} `yaml:"networks,omitempty"`
+	ServiceDefaults RawService             `yaml:"x-service-defaults,omitempty"`
+	Sensitive       []string               `yaml:"x-sensitive,omitempty"`
 }
 
 // NewServiceConfigs initializes a new Configs struct
@@ -262,4 +359,17 @@ type ParseOptions struct {
 	Validate    bool
 	Preprocess  func(RawServiceMap) (RawServiceMap, error)
 	Postprocess func(map[string]*ServiceConfig) (map[string]*ServiceConfig, error)
+	// Defaults is merged under every service when it is first defined, a
+	// top-level x-service-defaults block of a compose file takes precedence.
+	Defaults *ServiceConfig
+	// ProjectDefaults, when set, keeps the x-service-defaults blocks of the
+	// compose files merged, so that they apply to the services of the
+	// following files too.
+	ProjectDefaults *ProjectDefaults
+	// Provenance, when set, records the origin of the values of the merged
+	// services.
+	Provenance *Provenance
+	// Sensitive, when set, records the environment variables whose values
+	// must be masked when printed.
+	Sensitive *Sensitive
 }
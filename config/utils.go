@@ -1,5 +1,7 @@
 package config
 
+import "strings"
+
 func merge(existing, value interface{}) interface{} {
 	// append strings
 	if left, lok := existing.([]interface{}); lok {
@@ -25,6 +27,38 @@ func merge(existing, value interface{}) interface{} {
 	return value
 }
 
+// deepCopy copies the maps and slices of a raw value, so that merging into
+// the copy leaves the original untouched.
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case RawService:
+		result := RawService{}
+		for k, item := range v {
+			result[k] = deepCopy(item)
+		}
+		return result
+	case map[interface{}]interface{}:
+		result := map[interface{}]interface{}{}
+		for k, item := range v {
+			result[k] = deepCopy(item)
+		}
+		return result
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+		for k, item := range v {
+			result[k] = deepCopy(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = deepCopy(item)
+		}
+		return result
+	}
+	return value
+}
+
 func clone(in RawService) RawService {
 	result := RawService{}
 	for k, v := range in {
@@ -34,6 +68,12 @@ func clone(in RawService) RawService {
 	return result
 }
 
+// IsNamedVolume returns whether the source of a volume names a volume rather
+// than a path on the host.
+func IsNamedVolume(source string) bool {
+	return !strings.HasPrefix(source, ".") && !strings.HasPrefix(source, "/") && !strings.HasPrefix(source, "~")
+}
+
 func asString(obj interface{}) string {
 	if v, ok := obj.(string); ok {
 		return v
@@ -114,8 +114,13 @@ func (d *DaemonBuilder) Build(ctx context.Context, imageName string) error {
 	if err != nil {
 		return err
 	}
+	defer response.Body.Close()
 
 	err = jsonmessage.DisplayJSONMessagesStream(response.Body, buildBuff, outFd, isTerminalOut, nil)
+	if err != nil && ctx.Err() != nil {
+		// The stream was aborted by the cancellation of the context
+		return ctx.Err()
+	}
 	if err != nil {
 		if jerr, ok := err.(*jsonmessage.JSONError); ok {
 			// If no error code is set, default to 1
@@ -1,6 +1,7 @@
 package container
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"math"
@@ -166,6 +167,51 @@ func (c *Container) Kill(ctx context.Context, signal string) error {
 	return c.client.ContainerKill(ctx, c.container.ID, signal)
 }
 
+// State inspects the container again and returns its current state.
+func (c *Container) State(ctx context.Context) (*types.ContainerState, error) {
+	if err := c.updateInnerContainer(ctx); err != nil {
+		return nil, err
+	}
+	return c.container.State, nil
+}
+
+// RestartCount returns the number of times the container was restarted by
+// its restart policy, as of the last inspection, like that of State.
+func (c *Container) RestartCount() int {
+	return c.container.RestartCount
+}
+
+// ExitCode implements project.ExitCoder. It inspects the container again and
+// returns the code it exited with.
+func (c *Container) ExitCode(ctx context.Context) (int, error) {
+	state, err := c.State(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return state.ExitCode, nil
+}
+
+// LastLogs returns the last lines of the output of the container.
+func (c *Container) LastLogs(ctx context.Context, lines int) (string, error) {
+	responseBody, err := c.client.ContainerLogs(ctx, c.container.ID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(lines),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer responseBody.Close()
+
+	var buf bytes.Buffer
+	if c.container.Config.Tty {
+		_, err = io.Copy(&buf, responseBody)
+	} else {
+		_, err = stdcopy.StdCopy(&buf, &buf, responseBody)
+	}
+	return buf.String(), err
+}
+
 // IsRunning returns the running state of the container.
 func (c *Container) IsRunning(ctx context.Context) bool {
 	return c.container.State.Running
@@ -394,6 +440,12 @@ func (c *Container) Hash() string {
 	return c.container.Config.Labels[labels.HASH.Str()]
 }
 
+// EncodedConfig returns the service configuration the container was created
+// with, as encoded by config.EncodeServiceConfig and stored as label.
+func (c *Container) EncodedConfig() string {
+	return c.container.Config.Labels[labels.CONFIG.Str()]
+}
+
 // Number returns the container number stored as label.
 func (c *Container) Number() (int, error) {
 	numberStr := c.container.Config.Labels[labels.NUMBER.Str()]
@@ -81,6 +81,10 @@ func PullImage(ctx context.Context, client client.ImageAPIClient, serviceName st
 	outFd, isTerminalOut := term.GetFdInfo(os.Stderr)
 
 	err = jsonmessage.DisplayJSONMessagesStream(responseBody, writeBuff, outFd, isTerminalOut, nil)
+	if err != nil && ctx.Err() != nil {
+		// The stream was aborted by the cancellation of the context
+		return ctx.Err()
+	}
 	if err != nil {
 		if jerr, ok := err.(*jsonmessage.JSONError); ok {
 			// If no error code is set, default to 1
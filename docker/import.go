@@ -0,0 +1,169 @@
+package docker
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/docker/service"
+	"github.com/docker/libcompose/project"
+	"github.com/sirupsen/logrus"
+)
+
+// ImportOptions holds the containers to import.
+type ImportOptions struct {
+	// Containers are container names or IDs.
+	Containers []string
+	// Labels are label filters, like "com.example.app=web" or
+	// "com.example.app", selecting running and stopped containers.
+	Labels []string
+}
+
+var invalidServiceChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// Import inspects containers and returns a builder holding a service for
+// each of them, named after the container, and the networks and volumes
+// they use, declared external so that the project uses them rather than
+// creating its own. Links, network modes and volumes_from that refer to
+// imported containers are rewritten to refer to their services.
+func Import(ctx context.Context, client client.APIClient, options ImportOptions) (*config.Builder, error) {
+	names, err := containersToImport(ctx, client, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("No container to import")
+	}
+
+	var infos []types.ContainerJSON
+	services := map[string]string{}
+	for _, name := range names {
+		info, err := client.ContainerInspect(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+		serviceName := invalidServiceChars.ReplaceAllString(strings.TrimPrefix(info.Name, "/"), "_")
+		services[info.ID] = serviceName
+		services[strings.TrimPrefix(info.Name, "/")] = serviceName
+	}
+
+	builder := config.NewBuilder()
+	networks := map[string]bool{}
+	volumes := map[string]bool{}
+	for _, info := range infos {
+		var imageConfig *container.Config
+		image, _, err := client.ImageInspectWithRaw(ctx, info.Image)
+		if err != nil {
+			logrus.Warnf("Failed to inspect image %s of %s, its defaults won't be left out: %v", info.Image, info.Name, err)
+		} else {
+			imageConfig = image.Config
+		}
+
+		serviceConfig := service.ConvertFromAPI(info, imageConfig)
+		resolveContainerReferences(serviceConfig, services)
+
+		if serviceConfig.Networks != nil {
+			for _, network := range serviceConfig.Networks.Networks {
+				networks[network.Name] = true
+			}
+		}
+		if serviceConfig.Volumes != nil {
+			for _, volume := range serviceConfig.Volumes.Volumes {
+				if volume.Source != "" && project.IsNamedVolume(volume.Source) {
+					volumes[volume.Source] = true
+				}
+			}
+		}
+
+		builder.Service(services[info.ID]).Configure(func(c *config.ServiceConfig) {
+			*c = *serviceConfig
+		})
+	}
+
+	for _, name := range sortedNames(networks) {
+		builder.Network(name).External("")
+	}
+	for _, name := range sortedNames(volumes) {
+		builder.Volume(name).External("")
+	}
+
+	return builder, nil
+}
+
+func containersToImport(ctx context.Context, client client.APIClient, options ImportOptions) ([]string, error) {
+	names := append([]string{}, options.Containers...)
+	if len(options.Labels) == 0 {
+		return names, nil
+	}
+
+	filter := filters.NewArgs()
+	for _, label := range options.Labels {
+		filter.Add("label", label)
+	}
+	containers, err := client.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filter,
+	})
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, name := range names {
+		seen[name] = true
+	}
+	for _, c := range containers {
+		if !seen[c.ID] {
+			seen[c.ID] = true
+			names = append(names, c.ID)
+		}
+	}
+	return names, nil
+}
+
+// resolveContainerReferences replaces the references to imported containers
+// by references to their services.
+func resolveContainerReferences(c *config.ServiceConfig, services map[string]string) {
+	var externalLinks []string
+	for _, link := range c.ExternalLinks {
+		parts := strings.SplitN(link, ":", 2)
+		if name, ok := services[parts[0]]; ok {
+			parts[0] = name
+			c.Links = append(c.Links, strings.Join(parts, ":"))
+		} else {
+			externalLinks = append(externalLinks, link)
+		}
+	}
+	c.ExternalLinks = externalLinks
+
+	if strings.HasPrefix(c.NetworkMode, "container:") {
+		if name, ok := services[strings.TrimPrefix(c.NetworkMode, "container:")]; ok {
+			c.NetworkMode = "service:" + name
+		}
+	}
+
+	for i, volumesFrom := range c.VolumesFrom {
+		parts := strings.SplitN(volumesFrom, ":", 2)
+		if name, ok := services[parts[0]]; ok {
+			parts[0] = name
+			c.VolumesFrom[i] = strings.Join(parts, ":")
+		}
+	}
+}
+
+func sortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,128 @@
+package docker
+
+import (
+	"fmt"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+)
+
+type importClient struct {
+	client.Client
+	containers map[string]types.ContainerJSON
+	listed     []types.Container
+	filters    []string
+}
+
+func (c *importClient) ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error) {
+	c.filters = options.Filters.Get("label")
+	return c.listed, nil
+}
+
+func (c *importClient) ContainerInspect(ctx context.Context, name string) (types.ContainerJSON, error) {
+	info, ok := c.containers[name]
+	if !ok {
+		return types.ContainerJSON{}, fmt.Errorf("No such container: %s", name)
+	}
+	return info, nil
+}
+
+func (c *importClient) ImageInspectWithRaw(ctx context.Context, image string) (types.ImageInspect, []byte, error) {
+	return types.ImageInspect{Config: &container.Config{}}, nil, nil
+}
+
+func newImportContainer(id, name string, hostConfig *container.HostConfig, mounts []types.MountPoint, networks map[string]*network.EndpointSettings) types.ContainerJSON {
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:         id,
+			Name:       "/" + name,
+			Image:      "sha256:" + id,
+			HostConfig: hostConfig,
+		},
+		Config:          &container.Config{Image: name, Hostname: id[:12]},
+		Mounts:          mounts,
+		NetworkSettings: &types.NetworkSettings{Networks: networks},
+	}
+}
+
+func TestImport(t *testing.T) {
+	web := newImportContainer("aaaaaaaaaaaaaaaa", "web", &container.HostConfig{
+		LogConfig: container.LogConfig{Type: "json-file"},
+		Links:     []string{"/db:/web/database", "/legacy:/web/legacy"},
+	}, []types.MountPoint{
+		{Type: mount.TypeVolume, Name: "static", Destination: "/static", RW: true},
+	}, map[string]*network.EndpointSettings{
+		"front": {IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: "172.16.238.10"}},
+	})
+	db := newImportContainer("bbbbbbbbbbbbbbbb", "db", &container.HostConfig{
+		LogConfig:   container.LogConfig{Type: "json-file"},
+		NetworkMode: "back",
+	}, nil, map[string]*network.EndpointSettings{"back": {}})
+	sidecar := newImportContainer("cccccccccccccccc", "web-sidecar.1", &container.HostConfig{
+		LogConfig:   container.LogConfig{Type: "json-file"},
+		NetworkMode: "container:aaaaaaaaaaaaaaaa",
+		VolumesFrom: []string{"web:ro"},
+	}, nil, nil)
+
+	apiClient := &importClient{
+		containers: map[string]types.ContainerJSON{
+			"web":              web,
+			"bbbbbbbbbbbbbbbb": db,
+			"cccccccccccccccc": sidecar,
+		},
+		listed: []types.Container{{ID: "bbbbbbbbbbbbbbbb"}, {ID: "cccccccccccccccc"}},
+	}
+
+	builder, err := Import(context.Background(), apiClient, ImportOptions{
+		Containers: []string{"web"},
+		Labels:     []string{"com.example.app=shop"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"com.example.app=shop"}, apiClient.filters)
+
+	content, err := builder.Marshal()
+	assert.Nil(t, err)
+	assert.Equal(t, `version: "2"
+services:
+  db:
+    image: db
+    networks:
+      back: {}
+  web:
+    external_links:
+    - legacy
+    image: web
+    links:
+    - db:database
+    networks:
+      front:
+        ipv4_address: 172.16.238.10
+    volumes:
+    - static:/static
+  web-sidecar.1:
+    image: web-sidecar.1
+    network_mode: service:web
+    volumes_from:
+    - web:ro
+volumes:
+  static:
+    external: true
+networks:
+  back:
+    external: true
+  front:
+    external: true
+`, string(content))
+
+	_, err = Import(context.Background(), apiClient, ImportOptions{Containers: []string{"missing"}})
+	assert.NotNil(t, err)
+	_, err = Import(context.Background(), &importClient{}, ImportOptions{Labels: []string{"com.example.app"}})
+	assert.NotNil(t, err)
+}
@@ -3,6 +3,7 @@ package network
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"golang.org/x/net/context"
@@ -11,6 +12,8 @@ import (
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/project"
+	"github.com/docker/libcompose/utils"
 	"github.com/docker/libcompose/yaml"
 )
 
@@ -53,24 +56,34 @@ func (n *Network) Remove(ctx context.Context) error {
 // EnsureItExists make sure the network exists and return an error if it does not exists
 // and cannot be created.
 func (n *Network) EnsureItExists(ctx context.Context) error {
+	missing, err := n.missing(ctx)
+	if missing {
+		return n.create(ctx)
+	}
+	return err
+}
+
+// missing checks if the network has to be created, and returns an error if
+// it does not exist and cannot be created.
+func (n *Network) missing(ctx context.Context) (bool, error) {
 	networkResource, err := n.Inspect(ctx)
 	if n.external {
 		if client.IsErrNotFound(err) {
 			// FIXME(vdemeester) introduce some libcompose error type
-			return fmt.Errorf("Network %s declared as external, but could not be found. Please create the network manually using docker network create %s and try again", n.fullName(), n.fullName())
+			return false, fmt.Errorf("Network %s declared as external, but could not be found. Please create the network manually using docker network create %s and try again", n.fullName(), n.fullName())
 		}
-		return err
+		return false, err
 	}
 	if err != nil && client.IsErrNotFound(err) {
-		return n.create(ctx)
+		return true, nil
 	}
 	if n.driver != "" && networkResource.Driver != n.driver {
-		return fmt.Errorf("Network %q needs to be recreated - driver has changed", n.fullName())
+		return false, fmt.Errorf("Network %q needs to be recreated - driver has changed", n.fullName())
 	}
 	if len(n.driverOptions) != 0 && !reflect.DeepEqual(networkResource.Options, n.driverOptions) {
-		return fmt.Errorf("Network %q needs to be recreated - options have changed", n.fullName())
+		return false, fmt.Errorf("Network %q needs to be recreated - options have changed", n.fullName())
 	}
-	return err
+	return false, err
 }
 
 func (n *Network) create(ctx context.Context) error {
@@ -150,6 +163,98 @@ func (n *Networks) Remove(ctx context.Context) error {
 	return nil
 }
 
+// RenderCommands implements project.CommandRenderer. It returns the docker
+// network create commands of the networks that aren't external.
+func (n *Networks) RenderCommands(ctx context.Context) ([][]string, error) {
+	if !n.networkEnabled {
+		return nil, nil
+	}
+	var commands [][]string
+	for _, network := range n.networks {
+		if network.external {
+			continue
+		}
+		commands = append(commands, network.createCommand())
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i][len(commands[i])-1] < commands[j][len(commands[j])-1]
+	})
+	return commands, nil
+}
+
+// Plan implements project.Planner. Up and create create the networks that
+// don't exist, down removes those that exist and aren't external.
+func (n *Networks) Plan(ctx context.Context, operation project.Operation, opts interface{}) ([]project.Action, error) {
+	if !n.networkEnabled {
+		return nil, nil
+	}
+	var actions []project.Action
+	for _, network := range n.networks {
+		switch operation {
+		case project.OperationUp, project.OperationCreate:
+			missing, err := network.missing(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if missing {
+				actions = append(actions, network.action(project.ActionCreate, "does not exist"))
+			}
+		case project.OperationDown:
+			if network.external {
+				continue
+			}
+			if _, err := network.Inspect(ctx); err != nil {
+				if client.IsErrNotFound(err) {
+					continue
+				}
+				return nil, err
+			}
+			actions = append(actions, network.action(project.ActionRemove, "project is down"))
+		}
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Name < actions[j].Name
+	})
+	return actions, nil
+}
+
+func (n *Network) action(actionType project.ActionType, reason string) project.Action {
+	return project.Action{
+		Type:   actionType,
+		Kind:   project.KindNetwork,
+		Name:   n.fullName(),
+		Reason: reason,
+	}
+}
+
+func (n *Network) createCommand() []string {
+	command := []string{"docker", "network", "create"}
+	if n.driver != "" {
+		command = append(command, "--driver", n.driver)
+	}
+	for _, option := range utils.SortedPairs(n.driverOptions) {
+		command = append(command, "--opt", option)
+	}
+	if n.ipam.Driver != "" {
+		command = append(command, "--ipam-driver", n.ipam.Driver)
+	}
+	for _, config := range n.ipam.Config {
+		if config.Subnet != "" {
+			command = append(command, "--subnet", config.Subnet)
+		}
+		if config.IPRange != "" {
+			command = append(command, "--ip-range", config.IPRange)
+		}
+		if config.Gateway != "" {
+			command = append(command, "--gateway", config.Gateway)
+		}
+		for _, address := range utils.SortedPairs(config.AuxAddress) {
+			command = append(command, "--aux-address", address)
+		}
+	}
+	return append(command, n.fullName())
+}
+
 // NetworksFromServices creates a new Networks struct based on networks configurations and
 // services configuration. If a network is defined but not used by any service, it will return
 // an error along the Networks.
@@ -1,6 +1,9 @@
 package docker
 
 import (
+	"fmt"
+	"strings"
+
 	"golang.org/x/net/context"
 
 	"github.com/docker/docker/api/types"
@@ -76,6 +79,49 @@ type Project struct {
 // RemoveOrphans implements project.RuntimeProject.RemoveOrphans.
 // It will remove orphan containers that are part of the project but not to any services.
 func (p *Project) RemoveOrphans(ctx context.Context, projectName string, serviceConfigs *config.ServiceConfigs) error {
+	client := p.clientFactory.Create(nil)
+	containers, err := p.orphans(ctx, projectName, serviceConfigs)
+	if err != nil {
+		return err
+	}
+	for _, container := range containers {
+		if err := client.ContainerKill(ctx, container.ID, "SIGKILL"); err != nil {
+			return err
+		}
+		if err := client.ContainerRemove(ctx, container.ID, types.ContainerRemoveOptions{
+			Force: true,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlanOrphans implements project.OrphansPlanner. It returns the kill and
+// removal of the orphan containers.
+func (p *Project) PlanOrphans(ctx context.Context, projectName string, serviceConfigs *config.ServiceConfigs) ([]project.Action, error) {
+	containers, err := p.orphans(ctx, projectName, serviceConfigs)
+	if err != nil {
+		return nil, err
+	}
+	var actions []project.Action
+	for _, container := range containers {
+		name := container.ID
+		if len(container.Names) > 0 {
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+		reason := fmt.Sprintf("service %s is not defined", container.Labels[labels.SERVICE.Str()])
+		actions = append(actions,
+			project.Action{Type: project.ActionKill, Kind: project.KindContainer, Name: name, Reason: reason},
+			project.Action{Type: project.ActionRemove, Kind: project.KindContainer, Name: name, Reason: reason},
+		)
+	}
+	return actions, nil
+}
+
+// orphans lists the running containers of the project that aren't part of
+// any service.
+func (p *Project) orphans(ctx context.Context, projectName string, serviceConfigs *config.ServiceConfigs) ([]types.Container, error) {
 	client := p.clientFactory.Create(nil)
 	filter := filters.NewArgs()
 	filter.Add("label", labels.PROJECT.EqString(projectName))
@@ -83,24 +129,13 @@ func (p *Project) RemoveOrphans(ctx context.Context, projectName string, service
 		Filters: filter,
 	})
 	if err != nil {
-		return err
-	}
-	currentServices := map[string]struct{}{}
-	for _, serviceName := range serviceConfigs.Keys() {
-		currentServices[serviceName] = struct{}{}
+		return nil, err
 	}
+	var orphans []types.Container
 	for _, container := range containers {
-		serviceLabel := container.Labels[labels.SERVICE.Str()]
-		if _, ok := currentServices[serviceLabel]; !ok {
-			if err := client.ContainerKill(ctx, container.ID, "SIGKILL"); err != nil {
-				return err
-			}
-			if err := client.ContainerRemove(ctx, container.ID, types.ContainerRemoveOptions{
-				Force: true,
-			}); err != nil {
-				return err
-			}
+		if !serviceConfigs.Has(container.Labels[labels.SERVICE.Str()]) {
+			orphans = append(orphans, container)
 		}
 	}
-	return nil
+	return orphans, nil
 }
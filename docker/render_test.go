@@ -0,0 +1,114 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/docker/client"
+	"github.com/docker/libcompose/docker/ctx"
+	"github.com/docker/libcompose/project"
+	"github.com/stretchr/testify/assert"
+)
+
+type renderClientFactory struct{}
+
+func (renderClientFactory) Create(service project.Service) client.APIClient {
+	return &client.Client{}
+}
+
+func TestRenderCommands(t *testing.T) {
+	p, err := NewProject(&ctx.Context{
+		Context: project.Context{
+			ComposeFiles: []string{"docker-compose.yml"},
+			ComposeBytes: [][]byte{[]byte(`version: '2'
+services:
+  web:
+    image: nginx
+    ports:
+      - "8080:80"
+    expose:
+      - "443"
+    links:
+      - app:backend
+    networks:
+      - front
+      - back
+    restart: on-failure:3
+  app:
+    image: myapp
+    entrypoint: [/bin/sh, -c]
+    command: run app
+    environment:
+      DEBUG: "1"
+    labels:
+      com.example.tier: back
+    healthcheck:
+      test: [CMD, wget, -q, "http://localhost/health check"]
+      retries: 3
+    depends_on:
+      - db
+    volumes:
+      - data:/var/lib/app
+    networks:
+      back:
+        aliases:
+          - api
+  db:
+    image: postgres
+    container_name: database
+    networks:
+      - back
+networks:
+  front:
+    driver_opts:
+      com.docker.network.bridge.enable_icc: "true"
+  back:
+    ipam:
+      config:
+        - subnet: 172.16.238.0/24
+volumes:
+  data:
+    driver: local
+`)},
+			ProjectName: "myapp",
+		},
+		ClientFactory: renderClientFactory{},
+		ConfigFile:    &configfile.ConfigFile{},
+	}, nil)
+	assert.Nil(t, err)
+
+	commands, err := p.(*project.Project).RenderCommands(context.Background())
+	assert.Nil(t, err)
+
+	var lines []string
+	for _, command := range commands {
+		lines = append(lines, strings.Join(command, " "))
+	}
+	assert.Equal(t, 7, len(lines))
+	assert.Equal(t, "docker network create --subnet 172.16.238.0/24 myapp_back", lines[0])
+	assert.Equal(t, "docker network create --opt com.docker.network.bridge.enable_icc=true myapp_front", lines[1])
+	assert.Equal(t, "docker volume create --driver local myapp_data", lines[2])
+
+	assert.True(t, strings.HasPrefix(lines[3], "docker run --detach --name database "), lines[3])
+	assert.Contains(t, lines[3], " --network myapp_back --network-alias db ")
+
+	app := lines[4]
+	assert.True(t, strings.HasPrefix(app, "docker run --detach --name myapp_app_1 --network myapp_back --network-alias app --network-alias api --entrypoint /bin/sh --env DEBUG=1 "), app)
+	assert.Contains(t, app, " --label com.example.tier=back ")
+	assert.Contains(t, app, " --health-cmd wget -q 'http://localhost/health check' --health-retries 3")
+	// The labels of libcompose are left out
+	for _, command := range commands {
+		for _, arg := range command {
+			assert.False(t, strings.HasPrefix(arg, "com.docker.compose."), arg)
+		}
+	}
+	assert.True(t, strings.HasSuffix(app, " --volume myapp_data:/var/lib/app myapp -c run app"), app)
+
+	web := lines[5]
+	assert.True(t, strings.HasPrefix(web, "docker run --detach --name myapp_web_1 --network myapp_front --network-alias web "), web)
+	assert.True(t, strings.HasSuffix(web, " --expose 443 --link myapp_app_1:backend --link myapp_app_1:myapp_app_1 --publish 8080:80 --restart on-failure:3 nginx"), web)
+	assert.Equal(t, "docker network connect --alias web myapp_back myapp_web_1", lines[6])
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/docker/cli/opts"
 	"github.com/docker/docker/api/types/container"
@@ -92,6 +93,24 @@ func restartPolicy(c *config.ServiceConfig) (*container.RestartPolicy, error) {
 	return &container.RestartPolicy{Name: restart.Name, MaximumRetryCount: restart.MaximumRetryCount}, nil
 }
 
+func healthcheck(c *config.ServiceConfig) *container.HealthConfig {
+	test := c.HealthCheck.TestCommand()
+	if test == nil {
+		return nil
+	}
+	healthcheck := &container.HealthConfig{
+		Test:    test,
+		Retries: c.HealthCheck.Retries,
+	}
+	if interval, err := time.ParseDuration(c.HealthCheck.Interval); err == nil {
+		healthcheck.Interval = interval
+	}
+	if timeout, err := time.ParseDuration(c.HealthCheck.Timeout); err == nil {
+		healthcheck.Timeout = timeout
+	}
+	return healthcheck
+}
+
 func ports(c *config.ServiceConfig) (map[nat.Port]struct{}, nat.PortMap, error) {
 	ports, binding, err := nat.ParsePortSpecs(c.Ports)
 	if err != nil {
@@ -167,6 +186,7 @@ func Convert(c *config.ServiceConfig, ctx project.Context, clientFactory compose
 		MacAddress:   c.MacAddress,
 		StopSignal:   c.StopSignal,
 		StopTimeout:  utils.DurationStrToSecondsInt(c.StopGracePeriod),
+		Healthcheck:  healthcheck(c),
 	}
 
 	ulimits := []*units.Ulimit{}
@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/libcompose/config"
@@ -215,3 +216,26 @@ func TestTmpfs(t *testing.T) {
 		"/run": "rw,noexec,nosuid,size=65536k",
 	}, hostCfg.Tmpfs))
 }
+
+func TestHealthCheck(t *testing.T) {
+	ctx := &ctx.Context{}
+	cfg, _, err := Convert(&config.ServiceConfig{
+		HealthCheck: config.HealthCheck{
+			Test:     yaml.Stringorslice{"curl -f http://localhost"},
+			Interval: "10s",
+			Timeout:  "2s",
+			Retries:  3,
+		},
+	}, ctx.Context, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, &container.HealthConfig{
+		Test:     []string{"CMD-SHELL", "curl -f http://localhost"},
+		Interval: 10 * time.Second,
+		Timeout:  2 * time.Second,
+		Retries:  3,
+	}, cfg.Healthcheck)
+
+	cfg, _, err = Convert(&config.ServiceConfig{}, ctx.Context, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, cfg.Healthcheck)
+}
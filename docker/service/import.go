@@ -0,0 +1,349 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/yaml"
+)
+
+const defaultShmSize = 64 * 1024 * 1024
+
+// ConvertFromAPI converts the docker API configuration of a container back
+// to a service configuration, the inverse of Convert. The configuration of
+// its image, which may be nil, is used to leave out the values the container
+// inherits from it. The defaults the engine fills in are left out too.
+//
+// Links are returned as external links, and the networks of the container
+// by name. User defined networks are given their name, the default bridge
+// one is left out.
+func ConvertFromAPI(info types.ContainerJSON, image *container.Config) *config.ServiceConfig {
+	if image == nil {
+		image = &container.Config{}
+	}
+	c := &config.ServiceConfig{}
+
+	if info.Config != nil {
+		convertConfigFromAPI(c, info, image)
+	}
+	if info.ContainerJSONBase != nil && info.HostConfig != nil {
+		convertHostConfigFromAPI(c, info.HostConfig)
+	}
+	c.Volumes = volumesFromAPI(info.Mounts, image)
+	c.Networks = networksFromAPI(info)
+
+	return c
+}
+
+func convertConfigFromAPI(c *config.ServiceConfig, info types.ContainerJSON, image *container.Config) {
+	cfg := info.Config
+
+	c.Image = cfg.Image
+	if !reflect.DeepEqual([]string(cfg.Cmd), []string(image.Cmd)) {
+		c.Command = yaml.Command(cfg.Cmd)
+	}
+	if !reflect.DeepEqual([]string(cfg.Entrypoint), []string(image.Entrypoint)) {
+		c.Entrypoint = yaml.Command(cfg.Entrypoint)
+	}
+
+	imageEnv := map[string]bool{}
+	for _, env := range image.Env {
+		imageEnv[env] = true
+	}
+	for _, env := range cfg.Env {
+		if !imageEnv[env] {
+			c.Environment = append(c.Environment, env)
+		}
+	}
+
+	for key, value := range cfg.Labels {
+		if strings.HasPrefix(key, "com.docker.compose.") {
+			continue
+		}
+		if imageValue, ok := image.Labels[key]; ok && imageValue == value {
+			continue
+		}
+		if c.Labels == nil {
+			c.Labels = yaml.SliceorMap{}
+		}
+		c.Labels[key] = value
+	}
+
+	// The engine names the host after the container ID
+	if info.ContainerJSONBase == nil || !strings.HasPrefix(info.ID, cfg.Hostname) {
+		c.Hostname = cfg.Hostname
+	}
+	c.DomainName = cfg.Domainname
+	if cfg.User != image.User {
+		c.User = cfg.User
+	}
+	if cfg.WorkingDir != image.WorkingDir {
+		c.WorkingDir = cfg.WorkingDir
+	}
+	c.Tty = cfg.Tty
+	c.StdinOpen = cfg.OpenStdin
+	c.MacAddress = cfg.MacAddress
+	if cfg.StopSignal != image.StopSignal && cfg.StopSignal != "SIGTERM" {
+		c.StopSignal = cfg.StopSignal
+	}
+	if cfg.StopTimeout != nil {
+		c.StopGracePeriod = (time.Duration(*cfg.StopTimeout) * time.Second).String()
+	}
+	if cfg.Healthcheck != nil && !reflect.DeepEqual(cfg.Healthcheck, image.Healthcheck) {
+		c.HealthCheck = healthcheckFromAPI(cfg.Healthcheck)
+	}
+
+	var published map[nat.Port][]nat.PortBinding
+	if info.ContainerJSONBase != nil && info.HostConfig != nil {
+		published = info.HostConfig.PortBindings
+	}
+	for port := range cfg.ExposedPorts {
+		if _, ok := image.ExposedPorts[port]; ok {
+			continue
+		}
+		if _, ok := published[port]; ok {
+			continue
+		}
+		c.Expose = append(c.Expose, portString(port))
+	}
+	sort.Strings(c.Expose)
+}
+
+func convertHostConfigFromAPI(c *config.ServiceConfig, hostConfig *container.HostConfig) {
+	c.CapAdd = hostConfig.CapAdd
+	c.CapDrop = hostConfig.CapDrop
+	c.GroupAdd = hostConfig.GroupAdd
+	c.ExtraHosts = hostConfig.ExtraHosts
+	c.Privileged = hostConfig.Privileged
+	c.DNS = yaml.Stringorslice(hostConfig.DNS)
+	c.DNSOpts = hostConfig.DNSOptions
+	c.DNSSearch = yaml.Stringorslice(hostConfig.DNSSearch)
+	if !hostConfig.Isolation.IsDefault() {
+		c.Isolation = string(hostConfig.Isolation)
+	}
+	if hostConfig.LogConfig.Type != "json-file" || len(hostConfig.LogConfig.Config) > 0 {
+		c.Logging = config.Log{
+			Driver:  hostConfig.LogConfig.Type,
+			Options: hostConfig.LogConfig.Config,
+		}
+	}
+	switch mode := hostConfig.NetworkMode; {
+	case mode.IsHost(), mode.IsNone(), mode.IsContainer():
+		c.NetworkMode = string(mode)
+	}
+	c.ReadOnly = hostConfig.ReadonlyRootfs
+	c.OomScoreAdj = yaml.StringorInt(hostConfig.OomScoreAdj)
+	c.Pid = string(hostConfig.PidMode)
+	c.Uts = string(hostConfig.UTSMode)
+	if ipc := hostConfig.IpcMode; ipc.IsHost() || ipc.IsContainer() {
+		c.Ipc = string(ipc)
+	}
+	c.Restart = restartFromAPI(hostConfig.RestartPolicy)
+	if hostConfig.ShmSize != defaultShmSize {
+		c.ShmSize = yaml.MemStringorInt(hostConfig.ShmSize)
+	}
+	c.SecurityOpt = hostConfig.SecurityOpt
+	for path, options := range hostConfig.Tmpfs {
+		if options != "" {
+			path += ":" + options
+		}
+		c.Tmpfs = append(c.Tmpfs, path)
+	}
+	sort.Strings(c.Tmpfs)
+	c.VolumeDriver = hostConfig.VolumeDriver
+	c.VolumesFrom = hostConfig.VolumesFrom
+
+	for _, link := range hostConfig.Links {
+		// Links are "/target:/container/alias"
+		parts := strings.SplitN(link, ":", 2)
+		target := strings.TrimPrefix(parts[0], "/")
+		if len(parts) == 2 {
+			if alias := parts[1][strings.LastIndex(parts[1], "/")+1:]; alias != target {
+				target += ":" + alias
+			}
+		}
+		c.ExternalLinks = append(c.ExternalLinks, target)
+	}
+
+	var ports []string
+	for port, bindings := range hostConfig.PortBindings {
+		for _, binding := range bindings {
+			ports = append(ports, publishedPortString(port, binding))
+		}
+	}
+	sort.Strings(ports)
+	c.Ports = ports
+
+	resources := hostConfig.Resources
+	c.CgroupParent = resources.CgroupParent
+	c.MemLimit = yaml.MemStringorInt(resources.Memory)
+	c.MemReservation = yaml.MemStringorInt(resources.MemoryReservation)
+	// The engine sets the swap limit to twice the memory one by default
+	if resources.MemorySwap > 0 && resources.MemorySwap != 2*resources.Memory {
+		c.MemSwapLimit = yaml.MemStringorInt(resources.MemorySwap)
+	}
+	if resources.MemorySwappiness != nil && *resources.MemorySwappiness >= 0 {
+		c.MemSwappiness = yaml.MemStringorInt(*resources.MemorySwappiness)
+	}
+	if resources.CPUShares != 1024 {
+		c.CPUShares = yaml.StringorInt(resources.CPUShares)
+	}
+	c.CPUQuota = yaml.StringorInt(resources.CPUQuota)
+	c.CPUSet = resources.CpusetCpus
+	if resources.OomKillDisable != nil {
+		c.OomKillDisable = *resources.OomKillDisable
+	}
+	for _, ulimit := range resources.Ulimits {
+		c.Ulimits.Elements = append(c.Ulimits.Elements, yaml.NewUlimit(ulimit.Name, ulimit.Soft, ulimit.Hard))
+	}
+	for _, device := range resources.Devices {
+		c.Devices = append(c.Devices, deviceString(device))
+	}
+}
+
+func healthcheckFromAPI(healthcheck *container.HealthConfig) config.HealthCheck {
+	if len(healthcheck.Test) == 1 && healthcheck.Test[0] == "NONE" {
+		return config.HealthCheck{Disable: true}
+	}
+	result := config.HealthCheck{
+		Test:    yaml.Stringorslice(healthcheck.Test),
+		Retries: healthcheck.Retries,
+	}
+	if healthcheck.Interval > 0 {
+		result.Interval = healthcheck.Interval.String()
+	}
+	if healthcheck.Timeout > 0 {
+		result.Timeout = healthcheck.Timeout.String()
+	}
+	return result
+}
+
+func restartFromAPI(policy container.RestartPolicy) string {
+	switch {
+	case policy.Name == "" || policy.IsNone():
+		return ""
+	case policy.IsOnFailure() && policy.MaximumRetryCount > 0:
+		return fmt.Sprintf("%s:%d", policy.Name, policy.MaximumRetryCount)
+	}
+	return policy.Name
+}
+
+func portString(port nat.Port) string {
+	if port.Proto() == "tcp" {
+		return port.Port()
+	}
+	return string(port)
+}
+
+func publishedPortString(port nat.Port, binding nat.PortBinding) string {
+	result := portString(port)
+	if binding.HostPort != "" {
+		result = binding.HostPort + ":" + result
+	}
+	if binding.HostIP != "" && binding.HostIP != "0.0.0.0" {
+		if binding.HostPort == "" {
+			result = ":" + result
+		}
+		result = binding.HostIP + ":" + result
+	}
+	return result
+}
+
+func deviceString(device container.DeviceMapping) string {
+	result := device.PathOnHost
+	if device.PathInContainer != device.PathOnHost {
+		result += ":" + device.PathInContainer
+	}
+	if device.CgroupPermissions != "" && device.CgroupPermissions != "rwm" {
+		result += ":" + device.CgroupPermissions
+	}
+	return result
+}
+
+func volumesFromAPI(mounts []types.MountPoint, image *container.Config) *yaml.Volumes {
+	var volumes []*yaml.Volume
+	for _, m := range mounts {
+		volume := &yaml.Volume{Destination: m.Destination}
+		switch m.Type {
+		case mount.TypeBind:
+			volume.Source = m.Source
+		case mount.TypeVolume:
+			if isAnonymousVolume(m.Name) {
+				// The engine creates the volumes of the image
+				if _, ok := image.Volumes[m.Destination]; ok {
+					continue
+				}
+			} else {
+				volume.Source = m.Name
+			}
+		default:
+			continue
+		}
+		if !m.RW {
+			volume.AccessMode = "ro"
+		}
+		volumes = append(volumes, volume)
+	}
+	if len(volumes) == 0 {
+		return nil
+	}
+	sort.Slice(volumes, func(i, j int) bool {
+		return volumes[i].Destination < volumes[j].Destination
+	})
+	return &yaml.Volumes{Volumes: volumes}
+}
+
+// isAnonymousVolume checks if a volume name is one generated by the engine.
+func isAnonymousVolume(name string) bool {
+	if len(name) != 64 {
+		return false
+	}
+	for _, c := range name {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+func networksFromAPI(info types.ContainerJSON) *yaml.Networks {
+	if info.NetworkSettings == nil || info.ContainerJSONBase == nil || info.HostConfig == nil {
+		return nil
+	}
+	if mode := info.HostConfig.NetworkMode; mode.IsHost() || mode.IsNone() || mode.IsContainer() {
+		return nil
+	}
+	var networks []*yaml.Network
+	for name, endpoint := range info.NetworkSettings.Networks {
+		if name == "bridge" {
+			continue
+		}
+		network := &yaml.Network{Name: name}
+		for _, alias := range endpoint.Aliases {
+			// The engine adds the short ID of the container as alias
+			if !strings.HasPrefix(info.ID, alias) {
+				network.Aliases = append(network.Aliases, alias)
+			}
+		}
+		if endpoint.IPAMConfig != nil {
+			network.IPv4Address = endpoint.IPAMConfig.IPv4Address
+			network.IPv6Address = endpoint.IPAMConfig.IPv6Address
+		}
+		networks = append(networks, network)
+	}
+	if len(networks) == 0 {
+		return nil
+	}
+	sort.Slice(networks, func(i, j int) bool {
+		return networks[i].Name < networks[j].Name
+	})
+	return &yaml.Networks{Networks: networks}
+}
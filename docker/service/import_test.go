@@ -0,0 +1,152 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
+	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+const containerID = "3f4e8b2a9c1d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f"
+
+func TestConvertFromAPI(t *testing.T) {
+	swappiness := int64(-1)
+	image := &container.Config{
+		Cmd:          []string{"nginx", "-g", "daemon off;"},
+		Env:          []string{"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin", "NGINX_VERSION=1.11"},
+		Labels:       map[string]string{"maintainer": "nginx"},
+		ExposedPorts: nat.PortSet{"80/tcp": {}},
+		Volumes:      map[string]struct{}{"/var/cache/nginx": {}},
+		StopSignal:   "SIGQUIT",
+	}
+	info := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:   containerID,
+			Name: "/web",
+			HostConfig: &container.HostConfig{
+				CapAdd:      []string{"NET_ADMIN"},
+				LogConfig:   container.LogConfig{Type: "json-file"},
+				NetworkMode: "front",
+				IpcMode:     "shareable",
+				RestartPolicy: container.RestartPolicy{
+					Name:              "on-failure",
+					MaximumRetryCount: 3,
+				},
+				ShmSize: 64 * 1024 * 1024,
+				Links:   []string{"/db:/web/database", "/cache:/web/cache"},
+				PortBindings: nat.PortMap{
+					"80/tcp":  {{HostIP: "0.0.0.0", HostPort: "8080"}},
+					"53/udp":  {{HostIP: "127.0.0.1", HostPort: ""}},
+					"443/tcp": {{HostPort: "8443"}},
+				},
+				Tmpfs: map[string]string{"/run": "", "/tmp": "size=64m"},
+				Resources: container.Resources{
+					Memory:           512 * 1024 * 1024,
+					MemorySwap:       1024 * 1024 * 1024,
+					MemorySwappiness: &swappiness,
+					CPUShares:        1024,
+					Ulimits:          []*units.Ulimit{{Name: "nofile", Soft: 20000, Hard: 40000}},
+					Devices: []container.DeviceMapping{
+						{PathOnHost: "/dev/fuse", PathInContainer: "/dev/fuse", CgroupPermissions: "rwm"},
+						{PathOnHost: "/dev/sda", PathInContainer: "/dev/xvda", CgroupPermissions: "r"},
+					},
+				},
+			},
+		},
+		Config: &container.Config{
+			Hostname:     containerID[:12],
+			Image:        "nginx:1.11",
+			Cmd:          []string{"nginx", "-g", "daemon off;"},
+			Env:          []string{"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin", "NGINX_VERSION=1.11", "DEBUG=true"},
+			Labels:       map[string]string{"maintainer": "nginx", "com.example.tier": "front", "com.docker.compose.project": "legacy"},
+			ExposedPorts: nat.PortSet{"80/tcp": {}, "443/tcp": {}, "53/udp": {}, "9000/tcp": {}},
+			StopSignal:   "SIGQUIT",
+			Healthcheck: &container.HealthConfig{
+				Test:     []string{"CMD-SHELL", "curl -f http://localhost"},
+				Interval: 30 * time.Second,
+				Retries:  3,
+			},
+		},
+		Mounts: []types.MountPoint{
+			{Type: mount.TypeBind, Source: "/srv/static", Destination: "/usr/share/nginx/html", RW: false},
+			{Type: mount.TypeVolume, Name: "logs", Destination: "/var/log/nginx", RW: true},
+			{Type: mount.TypeVolume, Name: containerID, Destination: "/var/cache/nginx", RW: true},
+			{Type: mount.TypeVolume, Name: containerID, Destination: "/scratch", RW: true},
+		},
+		NetworkSettings: &types.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				"front": {
+					Aliases:    []string{containerID[:12], "www"},
+					IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: "172.16.238.10"},
+				},
+				"back": {},
+			},
+		},
+	}
+
+	expected := &config.ServiceConfig{
+		Image:         "nginx:1.11",
+		Environment:   yaml.MaporEqualSlice{"DEBUG=true"},
+		Labels:        yaml.SliceorMap{"com.example.tier": "front"},
+		Expose:        []string{"9000"},
+		CapAdd:        []string{"NET_ADMIN"},
+		Restart:       "on-failure:3",
+		ExternalLinks: []string{"db:database", "cache"},
+		Ports:         []string{"127.0.0.1::53/udp", "8080:80", "8443:443"},
+		Tmpfs:         yaml.Stringorslice{"/run", "/tmp:size=64m"},
+		MemLimit:      512 * 1024 * 1024,
+		Ulimits:       yaml.Ulimits{Elements: []yaml.Ulimit{yaml.NewUlimit("nofile", 20000, 40000)}},
+		Devices:       []string{"/dev/fuse", "/dev/sda:/dev/xvda:r"},
+		HealthCheck: config.HealthCheck{
+			Test:     yaml.Stringorslice{"CMD-SHELL", "curl -f http://localhost"},
+			Interval: "30s",
+			Retries:  3,
+		},
+		Volumes: &yaml.Volumes{Volumes: []*yaml.Volume{
+			{Destination: "/scratch"},
+			{Source: "/srv/static", Destination: "/usr/share/nginx/html", AccessMode: "ro"},
+			{Source: "logs", Destination: "/var/log/nginx"},
+		}},
+		Networks: &yaml.Networks{Networks: []*yaml.Network{
+			{Name: "back"},
+			{Name: "front", Aliases: []string{"www"}, IPv4Address: "172.16.238.10"},
+		}},
+	}
+	assert.Equal(t, expected, ConvertFromAPI(info, image))
+}
+
+func TestConvertFromAPIWithoutImage(t *testing.T) {
+	info := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID: containerID,
+			HostConfig: &container.HostConfig{
+				NetworkMode: "host",
+				Resources:   container.Resources{CPUShares: 512},
+			},
+		},
+		Config: &container.Config{
+			Hostname: "web.local",
+			Image:    "busybox",
+			Cmd:      []string{"top"},
+		},
+		NetworkSettings: &types.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{"host": {}},
+		},
+	}
+	assert.Equal(t, &config.ServiceConfig{
+		Image:       "busybox",
+		Command:     yaml.Command{"top"},
+		Hostname:    "web.local",
+		NetworkMode: "host",
+		CPUShares:   512,
+		Logging:     config.Log{},
+	}, ConvertFromAPI(info, nil))
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/docker/container"
+	"github.com/docker/libcompose/docker/image"
+	"github.com/docker/libcompose/project"
+	"github.com/docker/libcompose/project/options"
+)
+
+// Plan implements project.Planner. It takes the decisions of Up, Create,
+// Delete and of the service part of Down without acting on them.
+func (s *Service) Plan(ctx context.Context, operation project.Operation, opts interface{}) ([]project.Action, error) {
+	switch operation {
+	case project.OperationUp:
+		return s.planUp(ctx, opts.(options.Up), true)
+	case project.OperationCreate:
+		return s.planUp(ctx, options.Up{Create: opts.(options.Create)}, false)
+	case project.OperationDown:
+		return s.planDown(ctx, opts.(options.Down))
+	case project.OperationDelete:
+		return s.planDelete(ctx, opts.(options.Delete))
+	}
+	return nil, fmt.Errorf("Operation %s can't be planned", operation)
+}
+
+func (s *Service) planUp(ctx context.Context, options options.Up, start bool) ([]project.Action, error) {
+	containers, err := s.collectContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []project.Action
+	// Up doesn't look at the image of existing containers it won't recreate
+	if !start || len(containers) == 0 || !options.NoRecreate {
+		imageAction, err := s.planImage(ctx, options.NoBuild, options.ForceBuild)
+		if err != nil {
+			return nil, err
+		}
+		if imageAction != nil {
+			actions = append(actions, *imageAction)
+			if imageAction.Type == project.ActionFail {
+				return actions, nil
+			}
+		}
+	}
+
+	if len(containers) == 0 {
+		namer, err := s.namer(ctx, 1)
+		if err != nil {
+			return nil, err
+		}
+		name, _ := namer.Next()
+		actions = append(actions, s.containerAction(project.ActionCreate, name, "no container exists"))
+		if start {
+			actions = append(actions, s.containerAction(project.ActionStart, name, "created"))
+		}
+		return actions, nil
+	}
+
+	var outdated []*container.Container
+	reasons := map[*container.Container]string{}
+	for _, c := range containers {
+		reason := "recreation is disabled"
+		if !options.NoRecreate {
+			if reason, err = s.outOfSyncReason(ctx, c); err != nil {
+				return nil, err
+			}
+			if options.ForceRecreate {
+				reason = "recreation is forced"
+			}
+		}
+		if reason != "" && !options.NoRecreate {
+			outdated = append(outdated, c)
+			reasons[c] = reason
+			continue
+		}
+		if reason == "" {
+			reason = "up to date"
+		}
+		if start && !c.IsRunning(ctx) {
+			actions = append(actions, s.containerAction(project.ActionStart, c.Name(), "not running"))
+			continue
+		}
+		actions = append(actions, s.containerAction(project.ActionKeep, c.Name(), reason))
+	}
+
+	// The containers out of sync come last: with an x-update-config, up
+	// recreates them in batches once the others are started
+	updateConfig := s.serviceConfig.UpdateConfig
+	rolling := start && !updateConfig.IsZero()
+	size := len(outdated)
+	if rolling && updateConfig.Parallelism > 0 && updateConfig.Parallelism < size {
+		size = updateConfig.Parallelism
+	}
+	for i, c := range outdated {
+		reason := reasons[c]
+		if rolling {
+			order := updateConfig.Order
+			if order == "" {
+				order = config.UpdateOrderStopFirst
+			}
+			reason += fmt.Sprintf(", batch %d of %d, %s", i/size+1, (len(outdated)+size-1)/size, order)
+		}
+		if start && options.Rollback {
+			reason += ", rolled back if it fails"
+		}
+		actions = append(actions, s.containerAction(project.ActionRecreate, c.Name(), reason))
+		if start {
+			actions = append(actions, s.containerAction(project.ActionStart, c.Name(), "recreated"))
+		}
+	}
+	return actions, nil
+}
+
+// planImage mirrors ensureImageExists.
+func (s *Service) planImage(ctx context.Context, noBuild bool, forceBuild bool) (*project.Action, error) {
+	if forceBuild {
+		return s.imageAction(project.ActionBuild, "build is forced"), nil
+	}
+
+	exists, err := image.Exists(ctx, s.clientFactory.Create(s), s.imageName())
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, nil
+	}
+
+	if s.Config().Build.Context != "" {
+		if noBuild {
+			return s.imageAction(project.ActionFail, "image does not exist, but no-build was specified"), nil
+		}
+		return s.imageAction(project.ActionBuild, "image does not exist"), nil
+	}
+	if s.Config().Image == "" {
+		return nil, nil
+	}
+	return s.imageAction(project.ActionPull, "image does not exist"), nil
+}
+
+func (s *Service) planDown(ctx context.Context, opts options.Down) ([]project.Action, error) {
+	containers, err := s.collectContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []project.Action
+	for _, c := range containers {
+		if c.IsRunning(ctx) {
+			actions = append(actions, s.containerAction(project.ActionStop, c.Name(), "project is down"))
+		}
+		actions = append(actions, s.containerAction(project.ActionRemove, c.Name(), "project is down"))
+	}
+
+	if opts.RemoveImages == "all" || (opts.RemoveImages == "local" && s.Config().Image == "") {
+		exists, err := image.Exists(ctx, s.clientFactory.Create(s), s.imageName())
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			actions = append(actions, *s.imageAction(project.ActionRemove, fmt.Sprintf("--rmi %s", opts.RemoveImages)))
+		}
+	}
+	return actions, nil
+}
+
+func (s *Service) planDelete(ctx context.Context, options options.Delete) ([]project.Action, error) {
+	containers, err := s.collectContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []project.Action
+	for _, c := range containers {
+		switch {
+		case !c.IsRunning(ctx):
+			actions = append(actions, s.containerAction(project.ActionRemove, c.Name(), "stopped"))
+		case options.RemoveRunning:
+			actions = append(actions, s.containerAction(project.ActionRemove, c.Name(), "removal of running containers is forced"))
+		default:
+			actions = append(actions, s.containerAction(project.ActionKeep, c.Name(), "running"))
+		}
+	}
+	return actions, nil
+}
+
+func (s *Service) containerAction(actionType project.ActionType, name, reason string) project.Action {
+	return project.Action{
+		Type:    actionType,
+		Kind:    project.KindContainer,
+		Name:    name,
+		Service: s.name,
+		Reason:  reason,
+	}
+}
+
+func (s *Service) imageAction(actionType project.ActionType, reason string) *project.Action {
+	return &project.Action{
+		Type:    actionType,
+		Kind:    project.KindImage,
+		Name:    s.imageName(),
+		Service: s.name,
+		Reason:  reason,
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/labels"
+	"github.com/docker/libcompose/project"
+	"github.com/docker/libcompose/project/options"
+	"github.com/docker/libcompose/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+type notFoundError string
+
+func (e notFoundError) Error() string { return string(e) }
+func (e notFoundError) NotFound()     {}
+
+// planClient holds the containers of a service and the IDs of the images
+// that exist, by name.
+type planClient struct {
+	client.APIClient
+	containers []types.ContainerJSON
+	images     map[string]string
+}
+
+func (c *planClient) Create(service project.Service) client.APIClient {
+	return c
+}
+
+func (c *planClient) ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error) {
+	var containers []types.Container
+	for _, info := range c.containers {
+		containers = append(containers, types.Container{ID: info.ID, Labels: info.Config.Labels})
+	}
+	return containers, nil
+}
+
+func (c *planClient) ContainerInspect(ctx context.Context, id string) (types.ContainerJSON, error) {
+	for _, info := range c.containers {
+		if info.ID == id {
+			return info, nil
+		}
+	}
+	return types.ContainerJSON{}, notFoundError("No such container: " + id)
+}
+
+func (c *planClient) ImageInspectWithRaw(ctx context.Context, image string) (types.ImageInspect, []byte, error) {
+	id, ok := c.images[image]
+	if !ok {
+		return types.ImageInspect{}, nil, notFoundError("No such image: " + image)
+	}
+	return types.ImageInspect{ID: id}, nil, nil
+}
+
+func (c *planClient) add(number int, serviceConfig *config.ServiceConfig, image string, running bool) {
+	c.containers = append(c.containers, types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:    fmt.Sprintf("container%d", number),
+			Name:  fmt.Sprintf("myapp_web_%d", number),
+			Image: c.images[image],
+			State: &types.ContainerState{Running: running},
+		},
+		Config: &container.Config{
+			Image: image,
+			Labels: map[string]string{
+				labels.NUMBER.Str(): fmt.Sprint(number),
+				labels.HASH.Str():   config.GetServiceHash("web", serviceConfig),
+			},
+		},
+	})
+}
+
+func planActions(t *testing.T, s *Service, operation project.Operation, opts interface{}) []string {
+	actions, err := s.Plan(context.Background(), operation, opts)
+	assert.Nil(t, err)
+	var result []string
+	for _, action := range actions {
+		result = append(result, action.String())
+	}
+	return result
+}
+
+func TestPlanUp(t *testing.T) {
+	serviceConfig := &config.ServiceConfig{
+		Image: "nginx:1.13",
+		UpdateConfig: config.UpdateConfig{
+			Parallelism: 1,
+			Order:       config.UpdateOrderStartFirst,
+		},
+	}
+	apiClient := &planClient{images: map[string]string{"nginx:1.12": "sha256:12", "nginx:1.13": "sha256:13"}}
+	apiClient.add(1, serviceConfig, "nginx:1.13", true)
+	apiClient.add(2, serviceConfig, "nginx:1.13", false)
+	apiClient.add(3, serviceConfig, "nginx:1.12", true)
+	apiClient.add(4, serviceConfig, "nginx:1.12", true)
+
+	p := project.NewProject(&project.Context{}, nil, nil)
+	p.Name = "myapp"
+	s := &Service{name: "web", project: p, serviceConfig: serviceConfig, clientFactory: apiClient}
+
+	// The containers out of sync are recreated once the others are
+	// started, one at a time
+	assert.Equal(t, []string{
+		"keep container myapp_web_1 (web): up to date",
+		"start container myapp_web_2 (web): not running",
+		"recreate container myapp_web_3 (web): image changed from nginx:1.12 to nginx:1.13, batch 1 of 2, start-first, rolled back if it fails",
+		"start container myapp_web_3 (web): recreated",
+		"recreate container myapp_web_4 (web): image changed from nginx:1.12 to nginx:1.13, batch 2 of 2, start-first, rolled back if it fails",
+		"start container myapp_web_4 (web): recreated",
+	}, planActions(t, s, project.OperationUp, options.Up{Rollback: true}))
+
+	// Create doesn't start the containers nor follow the x-update-config
+	assert.Equal(t, []string{
+		"keep container myapp_web_1 (web): up to date",
+		"keep container myapp_web_2 (web): up to date",
+		"recreate container myapp_web_3 (web): image changed from nginx:1.12 to nginx:1.13",
+		"recreate container myapp_web_4 (web): image changed from nginx:1.12 to nginx:1.13",
+	}, planActions(t, s, project.OperationCreate, options.Create{}))
+
+	// The plan doesn't fail when up would fail the service
+	s.serviceConfig = &config.ServiceConfig{Build: yaml.Build{Context: "."}}
+	apiClient.containers = nil
+	assert.Equal(t, []string{
+		"fail image myapp_web (web): image does not exist, but no-build was specified",
+	}, planActions(t, s, project.OperationUp, options.Up{Create: options.Create{NoBuild: true}}))
+	assert.True(t, strings.HasPrefix(planActions(t, s, project.OperationUp, options.Up{})[0], "build image myapp_web"))
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/libcompose/labels"
+	"github.com/docker/libcompose/project"
+	"github.com/docker/libcompose/utils"
+)
+
+// plannedContainer is a container that would be created by libcompose,
+// referred to by its name.
+type plannedContainer struct {
+	name string
+}
+
+func (c *plannedContainer) ID() string {
+	return c.name
+}
+
+func (c *plannedContainer) Name() string {
+	return c.name
+}
+
+func (c *plannedContainer) Port(ctx context.Context, port string) (string, error) {
+	return "", fmt.Errorf("Container %s is not created", c.name)
+}
+
+func (c *plannedContainer) IsRunning(ctx context.Context) bool {
+	return false
+}
+
+// plannedName returns the name of the first container of a service.
+func plannedName(projectName string, service project.Service) string {
+	if containerName := service.Config().ContainerName; containerName != "" {
+		return containerName
+	}
+	return fmt.Sprintf(format, projectName, service.Name(), 1)
+}
+
+func (s *Service) plannedContainers(ctx context.Context, service project.Service) ([]project.Container, error) {
+	return []project.Container{&plannedContainer{plannedName(s.project.Name, service)}}, nil
+}
+
+// RenderCommands implements project.CommandRenderer. It returns the docker
+// run command equivalent to creating and starting the first container of
+// the service, followed by the docker network connect commands of its
+// other networks. The containers of the services it depends on are referred
+// to by the names they would be given.
+func (s *Service) RenderCommands(ctx context.Context) ([][]string, error) {
+	containerName := plannedName(s.project.Name, s)
+	configWrapper, err := s.containerConfig(ctx, s.serviceConfig, 1, false, s.plannedContainers)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"docker", "run", "--detach", "--name", containerName}
+	args = appendString(args, "--network", string(configWrapper.HostConfig.NetworkMode))
+	var commands [][]string
+	networks := s.serviceConfig.Networks
+	if networks != nil && len(networks.Networks) > 0 && networks.Networks[0].RealName == string(configWrapper.HostConfig.NetworkMode) {
+		// The container is connected to its networks again once started,
+		// with the aliases and addresses of the service
+		for i, network := range networks.Networks {
+			aliasFlag := "--alias"
+			if i == 0 {
+				aliasFlag = "--network-alias"
+			}
+			networkArgs := []string{aliasFlag, s.name}
+			networkArgs = appendStrings(networkArgs, aliasFlag, network.Aliases)
+			networkArgs = appendString(networkArgs, "--ip", network.IPv4Address)
+			networkArgs = appendString(networkArgs, "--ip6", network.IPv6Address)
+			if i == 0 {
+				args = append(args, networkArgs...)
+				continue
+			}
+			command := append([]string{"docker", "network", "connect"}, networkArgs...)
+			commands = append(commands, append(command, network.RealName, containerName))
+		}
+	}
+
+	args = append(args, configArgs(configWrapper.Config, configWrapper.HostConfig.PortBindings)...)
+	args = append(args, hostConfigArgs(configWrapper.HostConfig)...)
+	args = append(args, configWrapper.Config.Image)
+	if len(configWrapper.Config.Entrypoint) > 1 {
+		args = append(args, configWrapper.Config.Entrypoint[1:]...)
+	}
+	args = append(args, configWrapper.Config.Cmd...)
+
+	return append([][]string{args}, commands...), nil
+}
+
+func configArgs(config *container.Config, published nat.PortMap) []string {
+	var args []string
+	args = appendString(args, "--hostname", config.Hostname)
+	args = appendString(args, "--domainname", config.Domainname)
+	args = appendString(args, "--user", config.User)
+	args = appendString(args, "--workdir", config.WorkingDir)
+	args = appendBool(args, "--tty", config.Tty)
+	args = appendBool(args, "--interactive", config.OpenStdin)
+	args = appendString(args, "--mac-address", config.MacAddress)
+	args = appendString(args, "--stop-signal", config.StopSignal)
+	if config.StopTimeout != nil {
+		args = append(args, "--stop-timeout", strconv.Itoa(*config.StopTimeout))
+	}
+	if len(config.Entrypoint) > 0 {
+		// The other elements of the entrypoint are passed before the command
+		args = append(args, "--entrypoint", config.Entrypoint[0])
+	}
+	args = appendStrings(args, "--env", config.Env)
+	args = appendStrings(args, "--label", utils.SortedPairs(userLabels(config.Labels)))
+
+	var volumes []string
+	for volume := range config.Volumes {
+		volumes = append(volumes, volume)
+	}
+	sort.Strings(volumes)
+	args = appendStrings(args, "--volume", volumes)
+
+	var exposed []string
+	for port := range config.ExposedPorts {
+		// Published ports are exposed by --publish
+		if _, ok := published[port]; ok {
+			continue
+		}
+		exposed = append(exposed, portString(port))
+	}
+	sort.Strings(exposed)
+	args = appendStrings(args, "--expose", exposed)
+
+	if healthcheck := config.Healthcheck; healthcheck != nil && len(healthcheck.Test) > 0 {
+		switch healthcheck.Test[0] {
+		case "NONE":
+			args = append(args, "--no-healthcheck")
+		case "CMD":
+			// docker run only takes a shell command, into which the
+			// arguments are quoted
+			quoted := make([]string, len(healthcheck.Test)-1)
+			for i, arg := range healthcheck.Test[1:] {
+				quoted[i] = utils.ShellQuote(arg)
+			}
+			args = append(args, "--health-cmd", strings.Join(quoted, " "))
+		case "CMD-SHELL":
+			args = append(args, "--health-cmd", strings.Join(healthcheck.Test[1:], " "))
+		}
+		if healthcheck.Interval > 0 {
+			args = append(args, "--health-interval", healthcheck.Interval.String())
+		}
+		if healthcheck.Timeout > 0 {
+			args = append(args, "--health-timeout", healthcheck.Timeout.String())
+		}
+		if healthcheck.Retries > 0 {
+			args = append(args, "--health-retries", strconv.Itoa(healthcheck.Retries))
+		}
+	}
+	return args
+}
+
+// userLabels returns the labels of a container without those libcompose sets
+// to recognize the containers it manages.
+func userLabels(containerLabels map[string]string) map[string]string {
+	result := map[string]string{}
+	for key, value := range containerLabels {
+		if !strings.HasPrefix(key, labels.Prefix) {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func hostConfigArgs(hostConfig *container.HostConfig) []string {
+	var args []string
+	// The links are collected from a map
+	links := append([]string{}, hostConfig.Links...)
+	sort.Strings(links)
+	args = appendStrings(args, "--link", links)
+	args = appendStrings(args, "--volume", hostConfig.Binds)
+	args = appendStrings(args, "--volumes-from", hostConfig.VolumesFrom)
+	args = appendString(args, "--volume-driver", hostConfig.VolumeDriver)
+
+	var ports []string
+	for port, bindings := range hostConfig.PortBindings {
+		for _, binding := range bindings {
+			ports = append(ports, publishedPortString(port, binding))
+		}
+	}
+	sort.Strings(ports)
+	args = appendStrings(args, "--publish", ports)
+
+	args = appendStrings(args, "--cap-add", hostConfig.CapAdd)
+	args = appendStrings(args, "--cap-drop", hostConfig.CapDrop)
+	args = appendStrings(args, "--group-add", hostConfig.GroupAdd)
+	args = appendStrings(args, "--add-host", hostConfig.ExtraHosts)
+	args = appendBool(args, "--privileged", hostConfig.Privileged)
+	args = appendStrings(args, "--dns", hostConfig.DNS)
+	args = appendStrings(args, "--dns-option", hostConfig.DNSOptions)
+	args = appendStrings(args, "--dns-search", hostConfig.DNSSearch)
+	args = appendString(args, "--isolation", string(hostConfig.Isolation))
+	args = appendString(args, "--log-driver", hostConfig.LogConfig.Type)
+	args = appendStrings(args, "--log-opt", utils.SortedPairs(hostConfig.LogConfig.Config))
+	args = appendBool(args, "--read-only", hostConfig.ReadonlyRootfs)
+	if hostConfig.OomScoreAdj != 0 {
+		args = append(args, "--oom-score-adj", strconv.Itoa(hostConfig.OomScoreAdj))
+	}
+	args = appendString(args, "--pid", string(hostConfig.PidMode))
+	args = appendString(args, "--uts", string(hostConfig.UTSMode))
+	args = appendString(args, "--ipc", string(hostConfig.IpcMode))
+	if policy := hostConfig.RestartPolicy; policy.Name != "" && !policy.IsNone() {
+		restart := policy.Name
+		if policy.MaximumRetryCount > 0 {
+			restart = fmt.Sprintf("%s:%d", restart, policy.MaximumRetryCount)
+		}
+		args = append(args, "--restart", restart)
+	}
+	args = appendInt(args, "--shm-size", hostConfig.ShmSize)
+	args = appendStrings(args, "--security-opt", hostConfig.SecurityOpt)
+
+	var tmpfs []string
+	for path, options := range hostConfig.Tmpfs {
+		if options != "" {
+			path += ":" + options
+		}
+		tmpfs = append(tmpfs, path)
+	}
+	sort.Strings(tmpfs)
+	args = appendStrings(args, "--tmpfs", tmpfs)
+
+	resources := hostConfig.Resources
+	args = appendString(args, "--cgroup-parent", resources.CgroupParent)
+	args = appendInt(args, "--memory", resources.Memory)
+	args = appendInt(args, "--memory-reservation", resources.MemoryReservation)
+	args = appendInt(args, "--memory-swap", resources.MemorySwap)
+	// Convert always sets the swappiness, to 0 if the service doesn't
+	if resources.MemorySwappiness != nil && *resources.MemorySwappiness > 0 {
+		args = append(args, "--memory-swappiness", strconv.FormatInt(*resources.MemorySwappiness, 10))
+	}
+	args = appendInt(args, "--cpu-shares", resources.CPUShares)
+	args = appendInt(args, "--cpu-quota", resources.CPUQuota)
+	args = appendString(args, "--cpuset-cpus", resources.CpusetCpus)
+	if resources.OomKillDisable != nil {
+		args = appendBool(args, "--oom-kill-disable", *resources.OomKillDisable)
+	}
+	for _, ulimit := range resources.Ulimits {
+		args = append(args, "--ulimit", fmt.Sprintf("%s=%d:%d", ulimit.Name, ulimit.Soft, ulimit.Hard))
+	}
+	for _, device := range resources.Devices {
+		args = append(args, "--device", deviceString(device))
+	}
+	return args
+}
+
+func appendString(args []string, flag, value string) []string {
+	if value == "" {
+		return args
+	}
+	return append(args, flag, value)
+}
+
+func appendStrings(args []string, flag string, values []string) []string {
+	for _, value := range values {
+		args = append(args, flag, value)
+	}
+	return args
+}
+
+func appendBool(args []string, flag string, value bool) []string {
+	if !value {
+		return args
+	}
+	return append(args, flag)
+}
+
+func appendInt(args []string, flag string, value int64) []string {
+	if value == 0 {
+		return args
+	}
+	return append(args, flag, strconv.FormatInt(value, 10))
+}
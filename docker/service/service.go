@@ -325,29 +325,46 @@ func (s *Service) up(ctx context.Context, imageName string, create bool, options
 		containers = []*container.Container{c}
 	}
 
+	if create && !options.NoRecreate && !s.serviceConfig.UpdateConfig.IsZero() {
+		return s.rollingUp(ctx, containers, options)
+	}
+
 	return s.eachContainer(ctx, containers, func(c *container.Container) error {
 		var err error
-		if create {
+		if create && options.Rollback && !options.NoRecreate {
+			recreate, err := s.needsRecreate(ctx, c, options.ForceRecreate)
+			if err != nil {
+				return err
+			}
+			if recreate {
+				logrus.Infof("Recreating %s", s.name)
+				return s.updateContainer(ctx, c, config.UpdateOrderStopFirst, config.DefaultUpdateMonitor, true)
+			}
+		} else if create {
 			c, err = s.recreateIfNeeded(ctx, c, options.NoRecreate, options.ForceRecreate)
 			if err != nil {
 				return err
 			}
 		}
 
-		if err := s.connectContainerToNetworks(ctx, c, false); err != nil {
-			return err
-		}
+		return s.startContainer(ctx, c)
+	})
+}
 
-		err = c.Start(ctx)
+func (s *Service) startContainer(ctx context.Context, c *container.Container) error {
+	if err := s.connectContainerToNetworks(ctx, c, false); err != nil {
+		return err
+	}
 
-		if err == nil {
-			s.project.Notify(events.ContainerStarted, s.name, map[string]string{
-				"name": c.Name(),
-			})
-		}
+	err := c.Start(ctx)
 
-		return err
-	})
+	if err == nil {
+		s.project.Notify(events.ContainerStarted, s.name, map[string]string{
+			"name": c.Name(),
+		})
+	}
+
+	return err
 }
 
 func (s *Service) connectContainerToNetworks(ctx context.Context, c *container.Container, oneOff bool) error {
@@ -394,7 +411,7 @@ func (s *Service) NetworkDisconnect(ctx context.Context, c *container.Container,
 func (s *Service) NetworkConnect(ctx context.Context, c *container.Container, net *yaml.Network, oneOff bool) error {
 	containerID := c.ID()
 	client := s.clientFactory.Create(s)
-	internalLinks, err := s.getLinks()
+	internalLinks, err := s.getLinks(ctx, existingContainers)
 	if err != nil {
 		return err
 	}
@@ -463,6 +480,22 @@ func (s *Service) recreateIfNeeded(ctx context.Context, c *container.Container,
 }
 
 func (s *Service) recreate(ctx context.Context, c *container.Container) (*container.Container, error) {
+	newContainer, err := s.createReplacement(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Remove(ctx, false); err != nil {
+		logrus.Errorf("Failed to remove old container %s", c.Name())
+		return nil, err
+	}
+	logrus.Debugf("Removed old container %s %s", c.Name(), c.ID())
+	return newContainer, nil
+}
+
+// createReplacement renames the container out of the way and creates its
+// replacement, with its name and volumes. The container gets its name back
+// if the replacement can't be created.
+func (s *Service) createReplacement(ctx context.Context, c *container.Container) (*container.Container, error) {
 	name := c.Name()
 	id := c.ID()
 	newName := fmt.Sprintf("%s_%s", name, id[:12])
@@ -474,43 +507,109 @@ func (s *Service) recreate(ctx context.Context, c *container.Container) (*contai
 	namer := NewSingleNamer(name)
 	newContainer, err := s.createContainer(ctx, namer, id, nil, false)
 	if err != nil {
+		if renameErr := c.Rename(context.Background(), name); renameErr != nil {
+			logrus.Errorf("Failed to rename old container %s back: %v", name, renameErr)
+		}
 		return nil, err
 	}
-	newID := newContainer.ID()
-	logrus.Debugf("Created replacement container %s", newID)
-	if err := c.Remove(ctx, false); err != nil {
-		logrus.Errorf("Failed to remove old container %s", c.Name())
-		return nil, err
-	}
-	logrus.Debugf("Removed old container %s %s", c.Name(), id)
+	logrus.Debugf("Created replacement container %s", newContainer.ID())
 	return newContainer, nil
 }
 
 // OutOfSync checks if the container is out of sync with the service definition.
 // It looks if the the service hash container label is the same as the computed one.
 func (s *Service) OutOfSync(ctx context.Context, c *container.Container) (bool, error) {
+	reason, err := s.outOfSyncReason(ctx, c)
+	return reason != "", err
+}
+
+// outOfSyncReason returns why the container is out of sync with the service
+// definition, or an empty string if it isn't.
+func (s *Service) outOfSyncReason(ctx context.Context, c *container.Container) (string, error) {
 	if c.ImageConfig() != s.serviceConfig.Image {
 		logrus.Debugf("Images for %s do not match %s!=%s", c.Name(), c.ImageConfig(), s.serviceConfig.Image)
-		return true, nil
+		return fmt.Sprintf("image changed from %s to %s", c.ImageConfig(), s.serviceConfig.Image), nil
 	}
 
-	expectedHash := config.GetServiceHash(s.name, s.Config())
-	if c.Hash() != expectedHash {
-		logrus.Debugf("Hashes for %s do not match %s!=%s", c.Name(), c.Hash(), expectedHash)
-		return true, nil
+	if !config.ServiceHashMatches(s.name, s.Config(), c.Hash()) {
+		logrus.Debugf("Hashes for %s do not match %s!=%s", c.Name(), c.Hash(), config.GetServiceHash(s.name, s.Config()))
+		return s.configChangeReason(c), nil
 	}
 
 	image, err := image.InspectImage(ctx, s.clientFactory.Create(s), c.ImageConfig())
 	if err != nil {
 		if client.IsErrNotFound(err) {
 			logrus.Debugf("Image %s do not exist, do not know if it's out of sync", c.Image())
-			return false, nil
+			return "", nil
 		}
-		return false, err
+		return "", err
 	}
 
 	logrus.Debugf("Checking existing image name vs id: %s == %s", image.ID, c.Image())
-	return image.ID != c.Image(), err
+	if image.ID != c.Image() {
+		return fmt.Sprintf("image %s was updated", c.ImageConfig()), nil
+	}
+	return "", nil
+}
+
+// configChangeReason logs the configuration changes of a container whose
+// hash doesn't match, and returns the changed keys as reason.
+func (s *Service) configChangeReason(c *container.Container) string {
+	diff := s.containerDiff(c)
+	if diff.Err != nil {
+		logrus.Debugf("Failed to compare the configuration of %s: %v", c.Name(), diff.Err)
+		return "configuration changed"
+	}
+	if diff.Changes == nil {
+		return "configuration changed"
+	}
+	var keys []string
+	for _, change := range diff.Changes {
+		logrus.Debugf("Configuration of %s changed: %s", c.Name(), change)
+		keys = append(keys, change.Key)
+	}
+	if len(keys) == 0 {
+		// The name of the service is part of the hash
+		return "configuration changed"
+	}
+	return "configuration changed: " + strings.Join(keys, ", ")
+}
+
+// ContainerDiff holds the differences between the configuration a container
+// was created with and the current configuration of its service.
+type ContainerDiff struct {
+	Container string
+	// Changes is nil if the container doesn't hold its configuration, like
+	// the containers created by older versions of libcompose.
+	Changes []config.Change
+	Err     error
+}
+
+// Diff compares the configuration each container of the service was created
+// with to the current configuration of the service.
+func (s *Service) Diff(ctx context.Context) ([]ContainerDiff, error) {
+	containers, err := s.collectContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	diffs := make([]ContainerDiff, 0, len(containers))
+	for _, c := range containers {
+		diffs = append(diffs, s.containerDiff(c))
+	}
+	return diffs, nil
+}
+
+func (s *Service) containerDiff(c *container.Container) ContainerDiff {
+	diff := ContainerDiff{Container: c.Name()}
+	encoded := c.EncodedConfig()
+	if encoded == "" {
+		return diff
+	}
+	diff.Changes, diff.Err = config.DiffServiceConfig(encoded, s.Config())
+	if diff.Err == nil && diff.Changes == nil {
+		diff.Changes = []config.Change{}
+	}
+	return diff
 }
 
 func (s *Service) collectContainersAndDo(ctx context.Context, action func(*container.Container) error) error {
@@ -523,15 +622,35 @@ func (s *Service) collectContainersAndDo(ctx context.Context, action func(*conta
 
 func (s *Service) eachContainer(ctx context.Context, containers []*container.Container, action func(*container.Container) error) error {
 
+	// The containers share the slots of the project with the services. They
+	// run one at a time in the slot the service holds, and besides in the
+	// slots left.
+	slots := s.project.Slots()
+	own := utils.NewSemaphore(1)
 	tasks := utils.InParallel{}
 	for _, cont := range containers {
 		task := func(cont *container.Container) func() error {
 			return func() error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if slots != nil {
+					// The containers waiting for a slot aren't acted
+					// on once the context is canceled
+					select {
+					case own <- struct{}{}:
+						defer own.Release()
+					case slots <- struct{}{}:
+						defer slots.Release()
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
 				return action(cont)
 			}
 		}(cont)
 
-		tasks.Add(task)
+		tasks.AddNamed(cont.Name(), task)
 	}
 
 	return tasks.Wait()
@@ -574,19 +693,33 @@ func (s *Service) Delete(ctx context.Context, options options.Delete) error {
 // Log implements Service.Log. It returns the docker logs for each container related to the service.
 func (s *Service) Log(ctx context.Context, follow bool) error {
 	return s.collectContainersAndDo(ctx, func(c *container.Container) error {
-		containerNumber, err := c.Number()
-		if err != nil {
-			return err
-		}
-		name := fmt.Sprintf("%s_%d", s.name, containerNumber)
-		if s.Config().ContainerName != "" {
-			name = s.Config().ContainerName
-		}
-		l := s.context.LoggerFactory.CreateContainerLogger(name)
-		return c.Log(ctx, l, follow)
+		return s.logContainer(ctx, c, follow)
 	})
 }
 
+// LogContainer implements project.ContainerLogger. It prints out the logs of
+// the container with the specified ID, named like the service's others.
+func (s *Service) LogContainer(ctx context.Context, id string, follow bool) error {
+	c, err := container.New(ctx, s.clientFactory.Create(s), id)
+	if err != nil {
+		return err
+	}
+	return s.logContainer(ctx, c, follow)
+}
+
+func (s *Service) logContainer(ctx context.Context, c *container.Container, follow bool) error {
+	containerNumber, err := c.Number()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s_%d", s.name, containerNumber)
+	if s.Config().ContainerName != "" {
+		name = s.Config().ContainerName
+	}
+	l := s.context.LoggerFactory.CreateContainerLogger(name)
+	return c.Log(ctx, l, follow)
+}
+
 // Scale implements Service.Scale. It creates or removes containers to have the specified number
 // of related container to the service to run.
 func (s *Service) Scale(ctx context.Context, scale int, timeout int) error {
@@ -676,7 +809,7 @@ func (s *Service) RemoveImage(ctx context.Context, imageType options.ImageType)
 	}
 }
 
-var eventAttributes = []string{"image", "name"}
+var eventAttributes = []string{"image", "name", "exitCode"}
 
 // Events implements Service.Events. It listen to all real-time events happening
 // for the service, and put them into the specified chan.
@@ -695,7 +828,9 @@ func (s *Service) Events(ctx context.Context, evts chan events.ContainerEvent) e
 				service := event.Actor.Attributes[labels.SERVICE.Str()]
 				attributes := map[string]string{}
 				for _, attr := range eventAttributes {
-					attributes[attr] = event.Actor.Attributes[attr]
+					if value, ok := event.Actor.Attributes[attr]; ok {
+						attributes[attr] = value
+					}
 				}
 				e := events.ContainerEvent{
 					Service:    service,
@@ -19,6 +19,13 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// containerLister returns the containers of a service a container depends on.
+type containerLister func(ctx context.Context, service project.Service) ([]project.Container, error)
+
+func existingContainers(ctx context.Context, service project.Service) ([]project.Container, error) {
+	return service.Containers(ctx)
+}
+
 func (s *Service) createContainer(ctx context.Context, namer Namer, oldContainer string, configOverride *config.ServiceConfig, oneOff bool) (*composecontainer.Container, error) {
 	serviceConfig := s.serviceConfig
 	if configOverride != nil {
@@ -26,36 +33,61 @@ func (s *Service) createContainer(ctx context.Context, namer Namer, oldContainer
 		serviceConfig.Tty = configOverride.Tty
 		serviceConfig.StdinOpen = configOverride.StdinOpen
 	}
+
+	containerName, containerNumber := namer.Next()
+	configWrapper, err := s.containerConfig(ctx, serviceConfig, containerNumber, oneOff, existingContainers)
+	if err != nil {
+		return nil, err
+	}
+
+	// FIXME(vdemeester): oldContainer should be a Container instead of a string
+	client := s.clientFactory.Create(s)
+	if oldContainer != "" {
+		info, err := client.ContainerInspect(ctx, oldContainer)
+		if err != nil {
+			return nil, err
+		}
+		configWrapper.HostConfig.Binds = util.Merge(configWrapper.HostConfig.Binds, volumeBinds(configWrapper.Config.Volumes, &info))
+	}
+
+	logrus.Debugf("Creating container %s %#v", containerName, configWrapper)
+	// FIXME(vdemeester): long-term will be container.Create(…)
+	container, err := composecontainer.Create(ctx, client, containerName, configWrapper.Config, configWrapper.HostConfig, configWrapper.NetworkingConfig)
+	if err != nil {
+		return nil, err
+	}
+	s.project.Notify(events.ContainerCreated, s.name, map[string]string{
+		"name": containerName,
+	})
+	return container, nil
+}
+
+// containerConfig returns the API configuration of a container of the
+// service, the containers it depends on being listed by listContainers.
+func (s *Service) containerConfig(ctx context.Context, serviceConfig *config.ServiceConfig, containerNumber int, oneOff bool, listContainers containerLister) (*ConfigWrapper, error) {
 	configWrapper, err := ConvertToAPI(serviceConfig, s.context.Context, s.clientFactory)
 	if err != nil {
 		return nil, err
 	}
 	configWrapper.Config.Image = s.imageName()
 
-	containerName, containerNumber := namer.Next()
-
 	configWrapper.Config.Labels[labels.SERVICE.Str()] = s.name
 	configWrapper.Config.Labels[labels.PROJECT.Str()] = s.project.Name
 	configWrapper.Config.Labels[labels.HASH.Str()] = config.GetServiceHash(s.name, serviceConfig)
+	if encoded, err := config.EncodeServiceConfig(serviceConfig); err == nil {
+		configWrapper.Config.Labels[labels.CONFIG.Str()] = encoded
+	} else {
+		logrus.Warnf("Failed to store the configuration of %s: %v", s.name, err)
+	}
 	configWrapper.Config.Labels[labels.ONEOFF.Str()] = strings.Title(strconv.FormatBool(oneOff))
 	configWrapper.Config.Labels[labels.NUMBER.Str()] = fmt.Sprintf("%d", containerNumber)
 	configWrapper.Config.Labels[labels.VERSION.Str()] = project.ComposeVersion
 
-	err = s.populateAdditionalHostConfig(configWrapper.HostConfig)
+	err = s.populateAdditionalHostConfig(ctx, configWrapper.HostConfig, listContainers)
 	if err != nil {
 		return nil, err
 	}
 
-	// FIXME(vdemeester): oldContainer should be a Container instead of a string
-	client := s.clientFactory.Create(s)
-	if oldContainer != "" {
-		info, err := client.ContainerInspect(ctx, oldContainer)
-		if err != nil {
-			return nil, err
-		}
-		configWrapper.HostConfig.Binds = util.Merge(configWrapper.HostConfig.Binds, volumeBinds(configWrapper.Config.Volumes, &info))
-	}
-
 	networkConfig := configWrapper.NetworkingConfig
 	if configWrapper.HostConfig.NetworkMode != "" && configWrapper.HostConfig.NetworkMode.IsUserDefined() {
 		if networkConfig == nil {
@@ -75,20 +107,12 @@ func (s *Service) createContainer(ctx context.Context, namer Namer, oldContainer
 			networkConfig.EndpointsConfig[key] = conf
 		}
 	}
-	logrus.Debugf("Creating container %s %#v", containerName, configWrapper)
-	// FIXME(vdemeester): long-term will be container.Create(…)
-	container, err := composecontainer.Create(ctx, client, containerName, configWrapper.Config, configWrapper.HostConfig, networkConfig)
-	if err != nil {
-		return nil, err
-	}
-	s.project.Notify(events.ContainerCreated, s.name, map[string]string{
-		"name": containerName,
-	})
-	return container, nil
+	configWrapper.NetworkingConfig = networkConfig
+	return configWrapper, nil
 }
 
-func (s *Service) populateAdditionalHostConfig(hostConfig *containertypes.HostConfig) error {
-	links, err := s.getLinks()
+func (s *Service) populateAdditionalHostConfig(ctx context.Context, hostConfig *containertypes.HostConfig, listContainers containerLister) error {
+	links, err := s.getLinks(ctx, listContainers)
 	if err != nil {
 		return err
 	}
@@ -103,7 +127,7 @@ func (s *Service) populateAdditionalHostConfig(hostConfig *containertypes.HostCo
 			return err
 		}
 
-		containers, err := service.Containers(context.Background())
+		containers, err := listContainers(ctx, service)
 		if err != nil {
 			return err
 		}
@@ -131,7 +155,7 @@ func (s *Service) populateAdditionalHostConfig(hostConfig *containertypes.HostCo
 }
 
 // FIXME(vdemeester) this is temporary
-func (s *Service) getLinks() (map[string]string, error) {
+func (s *Service) getLinks(ctx context.Context, listContainers containerLister) (map[string]string, error) {
 	links := map[string]string{}
 	for _, link := range s.DependentServices() {
 		if !s.project.ServiceConfigs.Has(link.Target) {
@@ -144,7 +168,7 @@ func (s *Service) getLinks() (map[string]string, error) {
 		}
 
 		// FIXME(vdemeester) container should not know service
-		containers, err := service.Containers(context.Background())
+		containers, err := listContainers(ctx, service)
 		if err != nil {
 			return nil, err
 		}
@@ -1,9 +1,17 @@
 package service
 
 import (
+	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/types"
 	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/docker/container"
+	"github.com/docker/libcompose/project"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,3 +34,45 @@ func TestSpecifiesHostPort(t *testing.T) {
 		assert.False(t, service.specificiesHostPort())
 	}
 }
+
+func TestEachContainerParallelism(t *testing.T) {
+	projectContext := &project.Context{}
+	p := project.NewProject(projectContext, nil, nil)
+	s := &Service{name: "web", project: p}
+	var containers []*container.Container
+	for i := 1; i <= 4; i++ {
+		containers = append(containers, container.NewInspected(nil, &types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{Name: fmt.Sprintf("myapp_web_%d", i)},
+		}))
+	}
+
+	var running, maxRunning int32
+	action := func(c *container.Container) error {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	}
+
+	// Set once the project is created. The service
+	// holds one of the slots, its containers get the other one besides it.
+	projectContext.Parallelism = 3
+	slots := p.Slots()
+	slots.Acquire()
+	slots.Acquire()
+	assert.Nil(t, s.eachContainer(context.Background(), containers, action))
+	assert.Equal(t, int32(2), maxRunning)
+
+	// The containers still run in the slot of the service when the others
+	// are taken
+	slots.Acquire()
+	atomic.StoreInt32(&maxRunning, 0)
+	assert.Nil(t, s.eachContainer(context.Background(), containers, action))
+	assert.Equal(t, int32(1), maxRunning)
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/docker/container"
+	"github.com/docker/libcompose/project/events"
+	"github.com/docker/libcompose/project/options"
+	"github.com/docker/libcompose/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// rollingUp starts the containers of the service and recreates the ones that
+// are out of sync as its x-update-config sets: in batches of parallelism
+// containers, each batch ready before the next one starts after the delay.
+func (s *Service) rollingUp(ctx context.Context, containers []*container.Container, options options.Up) error {
+	updateConfig := s.serviceConfig.UpdateConfig
+	delay, err := updateConfig.DelayDuration()
+	if err != nil {
+		return err
+	}
+	monitor, err := updateConfig.MonitorDuration()
+	if err != nil {
+		return err
+	}
+
+	var upToDate, outdated []*container.Container
+	for _, c := range containers {
+		outOfSync, err := s.needsRecreate(ctx, c, options.ForceRecreate)
+		if err != nil {
+			return err
+		}
+		if outOfSync {
+			outdated = append(outdated, c)
+		} else {
+			upToDate = append(upToDate, c)
+		}
+	}
+
+	if err := s.eachContainer(ctx, upToDate, func(c *container.Container) error {
+		return s.startContainer(ctx, c)
+	}); err != nil {
+		return err
+	}
+
+	size := updateConfig.Parallelism
+	if size <= 0 || size > len(outdated) {
+		size = len(outdated)
+	}
+	errs := utils.MultiError{}
+	for start := 0; start < len(outdated); start += size {
+		if start > 0 && delay > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+		end := start + size
+		if end > len(outdated) {
+			end = len(outdated)
+		}
+
+		logrus.Infof("Recreating %s: %d to %d of %d", s.name, start+1, end, len(outdated))
+		err := s.eachContainer(ctx, outdated[start:end], func(c *container.Container) error {
+			return s.updateContainer(ctx, c, updateConfig.Order, monitor, options.Rollback)
+		})
+		if err == nil {
+			continue
+		}
+		if updateConfig.FailureAction != config.UpdateFailureContinue {
+			if left := len(outdated) - end; left > 0 {
+				logrus.Warnf("Pausing the update of %s, %d containers left out of sync", s.name, left)
+			}
+			return err
+		}
+		for name, err := range err.(utils.MultiError) {
+			errs[name] = err
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// needsRecreate returns whether the container is to be recreated by up.
+func (s *Service) needsRecreate(ctx context.Context, c *container.Container, forceRecreate bool) (bool, error) {
+	if forceRecreate {
+		return true, nil
+	}
+	return s.OutOfSync(ctx, c)
+}
+
+// updateContainer replaces the container by a new one, started and ready.
+// The previous container is stopped before the new one starts with the
+// stop-first order, and after it is ready with the start-first order. It is
+// then removed. If the new container fails, it is removed and the previous
+// one put back when rollback is set, and with the start-first order, where
+// the previous one still runs. The new container fails if it isn't ready
+// within monitor.
+func (s *Service) updateContainer(ctx context.Context, c *container.Container, order string, monitor time.Duration, rollback bool) error {
+	newContainer, err := s.createReplacement(ctx, c)
+	if err != nil {
+		return err
+	}
+	return s.switchContainer(ctx, c, newContainer, order, monitor, rollback)
+}
+
+// switchContainer starts the new container in place of the previous one, see
+// updateContainer.
+func (s *Service) switchContainer(ctx context.Context, previous, newContainer *container.Container, order string, monitor time.Duration, rollback bool) error {
+	startFirst := order == config.UpdateOrderStartFirst
+	if !startFirst {
+		if !rollback {
+			if err := s.stopAndRemove(ctx, previous); err != nil {
+				return err
+			}
+		} else if err := previous.Stop(ctx, s.stopTimeout(0)); err != nil {
+			return s.rollback(previous, newContainer, err)
+		}
+	}
+
+	err := s.startContainer(ctx, newContainer)
+	if err == nil {
+		err = s.waitReady(ctx, []*container.Container{newContainer}, monitor)
+	}
+	if err != nil {
+		if rollback {
+			return s.rollback(previous, newContainer, err)
+		}
+		if startFirst {
+			return s.keepPrevious(previous, newContainer, err)
+		}
+		return err
+	}
+
+	if startFirst || rollback {
+		return s.stopAndRemove(ctx, previous)
+	}
+	return nil
+}
+
+// keepPrevious removes the new container of a start-first update that
+// failed, and gives the previous one, still running, its name back. It goes
+// on even if the operation is canceled, and returns the error that caused it.
+func (s *Service) keepPrevious(previous, failed *container.Container, cause error) error {
+	ctx := context.Background()
+	name := previous.Name()
+	if err := failed.Remove(ctx, false); err != nil {
+		return fmt.Errorf("Failed to remove the replacement of %s: %v, after: %v", name, err, cause)
+	}
+	if err := previous.Rename(ctx, name); err != nil {
+		return fmt.Errorf("Failed to rename %s back: %v, after: %v", name, err, cause)
+	}
+	return fmt.Errorf("Kept %s: %v", name, cause)
+}
+
+// rollback removes the new container and puts the previous one back in its
+// place, started. It goes on even if the operation is canceled, and returns
+// the error that caused it.
+func (s *Service) rollback(previous, failed *container.Container, cause error) error {
+	ctx := context.Background()
+	name := previous.Name()
+	s.project.Notify(events.ContainerRollbackStart, s.name, map[string]string{
+		"name":  name,
+		"error": cause.Error(),
+	})
+
+	if err := failed.Remove(ctx, false); err != nil {
+		return fmt.Errorf("Failed to roll back %s: %v, after: %v", name, err, cause)
+	}
+	if err := previous.Rename(ctx, name); err != nil {
+		return fmt.Errorf("Failed to roll back %s: %v, after: %v", name, err, cause)
+	}
+	if err := previous.Start(ctx); err != nil {
+		return fmt.Errorf("Failed to roll back %s: %v, after: %v", name, err, cause)
+	}
+
+	s.project.Notify(events.ContainerRollback, s.name, map[string]string{
+		"name": name,
+	})
+	return fmt.Errorf("Rolled back %s: %v", name, cause)
+}
+
+// stopAndRemove stops the replaced container within the stop_grace_period
+// of the service, then removes it.
+func (s *Service) stopAndRemove(ctx context.Context, c *container.Container) error {
+	if err := c.Stop(ctx, s.stopTimeout(0)); err != nil {
+		logrus.Errorf("Failed to stop old container %s", c.Name())
+		return err
+	}
+	if err := c.Remove(ctx, false); err != nil {
+		logrus.Errorf("Failed to remove old container %s", c.Name())
+		return err
+	}
+	logrus.Debugf("Removed old container %s %s", c.Name(), c.ID())
+	return nil
+}
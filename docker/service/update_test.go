@@ -0,0 +1,138 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/docker/container"
+	"github.com/docker/libcompose/project"
+	"github.com/stretchr/testify/assert"
+)
+
+// engineClient keeps the containers of an update in memory. Started
+// containers get their state from started, running otherwise.
+type engineClient struct {
+	client.ContainerAPIClient
+	containers map[string]*types.ContainerJSON
+	started    map[string]types.ContainerState
+}
+
+func (c *engineClient) add(id, name string, state types.ContainerState) *container.Container {
+	c.containers[id] = &types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:    id,
+			Name:  name,
+			State: &state,
+		},
+		NetworkSettings: &types.NetworkSettings{},
+	}
+	info, _ := c.ContainerInspect(context.Background(), id)
+	return container.NewInspected(c, &info)
+}
+
+func (c *engineClient) ContainerInspect(ctx context.Context, id string) (types.ContainerJSON, error) {
+	info, ok := c.containers[id]
+	if !ok {
+		return types.ContainerJSON{}, fmt.Errorf("No such container: %s", id)
+	}
+	base := *info.ContainerJSONBase
+	state := *base.State
+	base.State = &state
+	result := *info
+	result.ContainerJSONBase = &base
+	return result, nil
+}
+
+func (c *engineClient) ContainerStart(ctx context.Context, id string, options types.ContainerStartOptions) error {
+	state, ok := c.started[id]
+	if !ok {
+		state = types.ContainerState{Status: "running", Running: true}
+	}
+	c.containers[id].State = &state
+	return nil
+}
+
+func (c *engineClient) ContainerStop(ctx context.Context, id string, timeout *time.Duration) error {
+	c.containers[id].State = &types.ContainerState{Status: "exited"}
+	return nil
+}
+
+func (c *engineClient) ContainerRemove(ctx context.Context, id string, options types.ContainerRemoveOptions) error {
+	delete(c.containers, id)
+	return nil
+}
+
+func (c *engineClient) ContainerRename(ctx context.Context, id, name string) error {
+	c.containers[id].Name = name
+	return nil
+}
+
+func (c *engineClient) ContainerLogs(ctx context.Context, id string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("No logs")
+}
+
+// switchContainers replaces a running container by a new one, started with
+// the given state, and returns the containers left.
+func switchContainers(t *testing.T, state types.ContainerState, order string, rollback bool) (map[string]*types.ContainerJSON, error) {
+	engine := &engineClient{
+		containers: map[string]*types.ContainerJSON{},
+		started:    map[string]types.ContainerState{"new": state},
+	}
+	previous := engine.add("previous", "myapp_web_1", types.ContainerState{Status: "running", Running: true})
+	assert.Nil(t, engine.ContainerRename(context.Background(), "previous", "myapp_web_1_previous"))
+	newContainer := engine.add("new", "myapp_web_1", types.ContainerState{Status: "created"})
+
+	s := &Service{
+		name:          "web",
+		project:       project.NewProject(&project.Context{}, nil, nil),
+		serviceConfig: &config.ServiceConfig{},
+	}
+	err := s.switchContainer(context.Background(), previous, newContainer, order, time.Millisecond, rollback)
+	return engine.containers, err
+}
+
+func TestSwitchContainer(t *testing.T) {
+	exited := types.ContainerState{Status: "exited", ExitCode: 1}
+
+	containers, err := switchContainers(t, types.ContainerState{Status: "running", Running: true}, config.UpdateOrderStartFirst, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"new"}, containerIDs(containers))
+
+	// A failed start-first update leaves the previous container in place
+	containers, err = switchContainers(t, exited, config.UpdateOrderStartFirst, false)
+	assert.Equal(t, "Kept myapp_web_1: Container myapp_web_1 exited with code 1", err.Error())
+	assert.Equal(t, []string{"previous"}, containerIDs(containers))
+	assert.Equal(t, "myapp_web_1", containers["previous"].Name)
+	assert.True(t, containers["previous"].State.Running)
+
+	containers, err = switchContainers(t, exited, config.UpdateOrderStopFirst, false)
+	assert.Equal(t, "Container myapp_web_1 exited with code 1", err.Error())
+	assert.Equal(t, []string{"new"}, containerIDs(containers))
+
+	containers, err = switchContainers(t, exited, config.UpdateOrderStopFirst, true)
+	assert.Equal(t, "Rolled back myapp_web_1: Container myapp_web_1 exited with code 1", err.Error())
+	assert.Equal(t, []string{"previous"}, containerIDs(containers))
+	assert.True(t, containers["previous"].State.Running)
+
+	// One-shot containers exiting with code 0 aren't rolled back
+	containers, err = switchContainers(t, types.ContainerState{Status: "exited"}, config.UpdateOrderStopFirst, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"new"}, containerIDs(containers))
+}
+
+func containerIDs(containers map[string]*types.ContainerJSON) []string {
+	var ids []string
+	for id := range containers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
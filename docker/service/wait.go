@@ -0,0 +1,112 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/libcompose/docker/container"
+)
+
+// waitInterval is the time between two inspections of the containers
+// waited for.
+const waitInterval = 500 * time.Millisecond
+
+// waitLogLines is the number of log lines of the containers that aren't
+// ready shown in the errors.
+const waitLogLines = 20
+
+// Wait implements project.Waiter. It inspects the containers of the service
+// until they are running and, if they have a healthcheck, healthy, or have
+// exited with code 0, like one-shot tasks. It fails as soon as one of them
+// exits with another code, restarts or is unhealthy, with its last log lines.
+func (s *Service) Wait(ctx context.Context) error {
+	pending, err := s.collectContainers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return fmt.Errorf("Service %s has no container", s.name)
+	}
+	return s.waitReady(ctx, pending, 0)
+}
+
+// waitReady inspects the containers until they are ready, see Wait. With a
+// timeout, it fails if they aren't all ready by then.
+func (s *Service) waitReady(ctx context.Context, pending []*container.Container, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	// The restart counts of the containers when first inspected, a
+	// container restarted since is failing
+	restarts := map[string]int{}
+	for {
+		var notReady []*container.Container
+		for _, c := range pending {
+			ready, err := s.containerReady(ctx, c)
+			if err != nil {
+				return err
+			}
+			if initial, ok := restarts[c.ID()]; !ok {
+				restarts[c.ID()] = c.RestartCount()
+			} else if c.RestartCount() > initial {
+				return s.notReadyError(ctx, c, "restarted")
+			}
+			if !ready {
+				notReady = append(notReady, c)
+			}
+		}
+		if len(notReady) == 0 {
+			return nil
+		}
+		pending = notReady
+		if timeout > 0 && time.Now().After(deadline) {
+			return s.notReadyError(ctx, pending[0], fmt.Sprintf("is not ready after %s", timeout))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+func (s *Service) containerReady(ctx context.Context, c *container.Container) (bool, error) {
+	state, err := c.State(ctx)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case state.Restarting:
+		return false, nil
+	case state.Running && state.Health != nil:
+		switch state.Health.Status {
+		case types.Healthy:
+			return true, nil
+		case types.Unhealthy:
+			reason := "is unhealthy"
+			if checks := state.Health.Log; len(checks) > 0 {
+				reason += ": " + strings.TrimSpace(checks[len(checks)-1].Output)
+			}
+			return false, s.notReadyError(ctx, c, reason)
+		}
+		return false, nil
+	case state.Running:
+		return true, nil
+	case state.Status == "created":
+		return false, nil
+	case state.Status == "exited" && state.ExitCode == 0:
+		return true, nil
+	}
+	return false, s.notReadyError(ctx, c, fmt.Sprintf("exited with code %d", state.ExitCode))
+}
+
+func (s *Service) notReadyError(ctx context.Context, c *container.Container, reason string) error {
+	logs, err := c.LastLogs(ctx, waitLogLines)
+	if err != nil || strings.TrimSpace(logs) == "" {
+		return fmt.Errorf("Container %s %s", c.Name(), reason)
+	}
+	return fmt.Errorf("Container %s %s, last logs:\n%s", c.Name(), reason, strings.TrimRight(logs, "\n"))
+}
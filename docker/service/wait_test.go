@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/libcompose/docker/container"
+	"github.com/stretchr/testify/assert"
+)
+
+// inspectClient returns the states in turn on each inspection of the
+// container, the last one once all were returned.
+type inspectClient struct {
+	client.ContainerAPIClient
+	states   []types.ContainerState
+	restarts []int
+	inspects int
+}
+
+func (c *inspectClient) ContainerInspect(ctx context.Context, id string) (types.ContainerJSON, error) {
+	i := c.inspects
+	if i >= len(c.states) {
+		i = len(c.states) - 1
+	}
+	c.inspects++
+	state := c.states[i]
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:           "abcdef",
+			Name:         "myapp_web_1",
+			State:        &state,
+			RestartCount: c.restarts[i],
+		},
+	}, nil
+}
+
+func (c *inspectClient) ContainerLogs(ctx context.Context, id string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("No logs")
+}
+
+func waitContainer(t *testing.T, apiClient *inspectClient, timeout time.Duration) error {
+	c, err := container.New(context.Background(), apiClient, "abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Service{name: "web"}
+	return s.waitReady(context.Background(), []*container.Container{c}, timeout)
+}
+
+func TestWaitReady(t *testing.T) {
+	starting := types.ContainerState{Status: "running", Running: true, Health: &types.Health{Status: types.Starting}}
+	healthy := types.ContainerState{Status: "running", Running: true, Health: &types.Health{Status: types.Healthy}}
+	restarting := types.ContainerState{Status: "restarting", Restarting: true}
+
+	err := waitContainer(t, &inspectClient{
+		states:   []types.ContainerState{starting, starting, healthy},
+		restarts: []int{1, 1, 1},
+	}, time.Minute)
+	assert.Nil(t, err)
+
+	err = waitContainer(t, &inspectClient{
+		states:   []types.ContainerState{starting, starting, restarting},
+		restarts: []int{0, 0, 1},
+	}, time.Minute)
+	assert.Equal(t, "Container myapp_web_1 restarted", err.Error())
+
+	err = waitContainer(t, &inspectClient{
+		states:   []types.ContainerState{starting},
+		restarts: []int{0},
+	}, time.Millisecond)
+	assert.Equal(t, "Container myapp_web_1 is not ready after 1ms", err.Error())
+
+	err = waitContainer(t, &inspectClient{
+		states:   []types.ContainerState{starting, {Status: "exited", ExitCode: 0}},
+		restarts: []int{0, 0},
+	}, time.Minute)
+	assert.Nil(t, err)
+
+	err = waitContainer(t, &inspectClient{
+		states:   []types.ContainerState{starting, {Status: "exited", ExitCode: 2}},
+		restarts: []int{0, 0},
+	}, time.Minute)
+	assert.Equal(t, "Container myapp_web_1 exited with code 2", err.Error())
+}
@@ -2,6 +2,7 @@ package volume
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/volume"
@@ -49,21 +50,31 @@ func (v *Volume) Remove(ctx context.Context) error {
 // EnsureItExists make sure the volume exists and return an error if it does not exists
 // and cannot be created.
 func (v *Volume) EnsureItExists(ctx context.Context) error {
+	missing, err := v.missing(ctx)
+	if missing {
+		return v.create(ctx)
+	}
+	return err
+}
+
+// missing checks if the volume has to be created, and returns an error if
+// it does not exist and cannot be created.
+func (v *Volume) missing(ctx context.Context) (bool, error) {
 	volumeResource, err := v.Inspect(ctx)
 	if v.external {
 		if client.IsErrNotFound(err) {
 			// FIXME(shouze) introduce some libcompose error type
-			return fmt.Errorf("Volume %s declared as external, but could not be found. Please create the volume manually using docker volume create %s and try again", v.name, v.name)
+			return false, fmt.Errorf("Volume %s declared as external, but could not be found. Please create the volume manually using docker volume create %s and try again", v.name, v.name)
 		}
-		return err
+		return false, err
 	}
 	if err != nil && client.IsErrNotFound(err) {
-		return v.create(ctx)
+		return true, nil
 	}
 	if volumeResource.Driver != v.driver {
-		return fmt.Errorf("Volume %q needs to be recreated - driver has changed", v.name)
+		return false, fmt.Errorf("Volume %q needs to be recreated - driver has changed", v.name)
 	}
-	return err
+	return false, err
 }
 
 func (v *Volume) create(ctx context.Context) error {
@@ -128,6 +139,86 @@ func (v *Volumes) Remove(ctx context.Context) error {
 	return nil
 }
 
+// RenderCommands implements project.CommandRenderer. It returns the docker
+// volume create commands of the volumes that aren't external.
+func (v *Volumes) RenderCommands(ctx context.Context) ([][]string, error) {
+	if !v.volumeEnabled {
+		return nil, nil
+	}
+	var commands [][]string
+	for _, volume := range v.volumes {
+		if volume.external {
+			continue
+		}
+		commands = append(commands, volume.createCommand())
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i][len(commands[i])-1] < commands[j][len(commands[j])-1]
+	})
+	return commands, nil
+}
+
+// Plan implements project.Planner. Up and create create the volumes that
+// don't exist, down removes those that exist and aren't external.
+func (v *Volumes) Plan(ctx context.Context, operation project.Operation, opts interface{}) ([]project.Action, error) {
+	if !v.volumeEnabled {
+		return nil, nil
+	}
+	var actions []project.Action
+	for _, volume := range v.volumes {
+		switch operation {
+		case project.OperationUp, project.OperationCreate:
+			missing, err := volume.missing(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if missing {
+				actions = append(actions, volume.action(project.ActionCreate, "does not exist"))
+			}
+		case project.OperationDown:
+			if volume.external {
+				continue
+			}
+			if _, err := volume.Inspect(ctx); err != nil {
+				if client.IsErrNotFound(err) {
+					continue
+				}
+				return nil, err
+			}
+			actions = append(actions, volume.action(project.ActionRemove, "volumes are removed"))
+		}
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Name < actions[j].Name
+	})
+	return actions, nil
+}
+
+func (v *Volume) action(actionType project.ActionType, reason string) project.Action {
+	return project.Action{
+		Type:   actionType,
+		Kind:   project.KindVolume,
+		Name:   v.fullName(),
+		Reason: reason,
+	}
+}
+
+func (v *Volume) createCommand() []string {
+	command := []string{"docker", "volume", "create"}
+	if v.driver != "" {
+		command = append(command, "--driver", v.driver)
+	}
+	var options []string
+	for key, value := range v.driverOptions {
+		options = append(options, key+"="+value)
+	}
+	sort.Strings(options)
+	for _, option := range options {
+		command = append(command, "--opt", option)
+	}
+	return append(command, v.fullName())
+}
+
 // VolumesFromServices creates a new Volumes struct based on volumes configurations and
 // services configuration. If a volume is defined but not used by any service, it will return
 // an error along the Volumes.
@@ -0,0 +1,628 @@
+// Package kube exports libcompose projects as Kubernetes manifests.
+package kube
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/labels"
+	"github.com/docker/libcompose/lookup"
+	"github.com/docker/libcompose/project"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultVolumeSize is the storage requested by the generated PersistentVolumeClaims.
+const DefaultVolumeSize = "1Gi"
+
+var invalidNameChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// handledKeys lists the service keys the exporter translates, any other key
+// set on a service is reported as unsupported.
+var handledKeys = map[string]bool{
+	"build":             true,
+	"cap_add":           true,
+	"cap_drop":          true,
+	"command":           true,
+	"cpu_quota":         true,
+	"cpu_shares":        true,
+	"entrypoint":        true,
+	"env_file":          true,
+	"environment":       true,
+	"expose":            true,
+	"extends":           true,
+	"extra_hosts":       true,
+	"healthcheck":       true,
+	"hostname":          true,
+	"image":             true,
+	"ipc":               true,
+	"labels":            true,
+	"mem_limit":         true,
+	"mem_reservation":   true,
+	"network_mode":      true,
+	"networks":          true,
+	"pid":               true,
+	"ports":             true,
+	"privileged":        true,
+	"read_only":         true,
+	"restart":           true,
+	"stdin_open":        true,
+	"stop_grace_period": true,
+	"tmpfs":             true,
+	"tty":               true,
+	"user":              true,
+	"volumes":           true,
+	"working_dir":       true,
+}
+
+// Unsupported describes a service key that couldn't be translated exactly.
+type Unsupported struct {
+	Key    string
+	Reason string
+}
+
+func (u Unsupported) String() string {
+	return u.Key + ": " + u.Reason
+}
+
+// Result holds the manifests exported from a project.
+type Result struct {
+	Objects []Object
+	// Unsupported lists, per service name, what couldn't be translated.
+	Unsupported map[string][]Unsupported
+}
+
+// Write writes the manifests to w as a stream of YAML documents.
+func (r *Result) Write(w io.Writer) error {
+	for i, object := range r.Objects {
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		bytes, err := yaml.Marshal(object)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type exporter struct {
+	project        *project.Project
+	resourceLookup config.ResourceLookup
+	result         *Result
+	// claims maps the engine name of a named volume to its claim name.
+	claims map[string]string
+	// volumeConfigs maps the engine name of a named volume to its config.
+	volumeConfigs map[string]*config.VolumeConfig
+	// generatedClaims holds the claims that are generated, by claim name.
+	generatedClaims map[string]*PersistentVolumeClaim
+}
+
+// Export walks the services, volumes and networks of a parsed project and
+// converts them to Deployments, Services, ConfigMaps and
+// PersistentVolumeClaims. The project name is used as a label on every object.
+func Export(p *project.Project) (*Result, error) {
+	e := &exporter{
+		project:         p,
+		resourceLookup:  &lookup.FileResourceLookup{},
+		result:          &Result{Unsupported: map[string][]Unsupported{}},
+		claims:          map[string]string{},
+		volumeConfigs:   map[string]*config.VolumeConfig{},
+		generatedClaims: map[string]*PersistentVolumeClaim{},
+	}
+
+	for name, volumeConfig := range p.VolumeConfigs {
+		engineName := p.Name + "_" + name
+		claim := dnsName(name)
+		if volumeConfig != nil && volumeConfig.External.External {
+			engineName = name
+			if volumeConfig.External.Name != "" {
+				engineName = volumeConfig.External.Name
+			}
+			claim = dnsName(engineName)
+		}
+		e.claims[engineName] = claim
+		e.volumeConfigs[engineName] = volumeConfig
+	}
+
+	names := p.ServiceConfigs.Keys()
+	sort.Strings(names)
+
+	services := []Object{}
+	deployments := []Object{}
+	configMaps := []Object{}
+	for _, name := range names {
+		serviceConfig, _ := p.ServiceConfigs.Get(name)
+		deployment, service, serviceConfigMaps, err := e.exportService(name, serviceConfig)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to export service %s: %v", name, err)
+		}
+		configMaps = append(configMaps, serviceConfigMaps...)
+		if service != nil {
+			services = append(services, service)
+		}
+		deployments = append(deployments, deployment)
+	}
+
+	claimNames := []string{}
+	for name := range e.generatedClaims {
+		claimNames = append(claimNames, name)
+	}
+	sort.Strings(claimNames)
+	for _, name := range claimNames {
+		e.result.Objects = append(e.result.Objects, e.generatedClaims[name])
+	}
+	e.result.Objects = append(e.result.Objects, configMaps...)
+	e.result.Objects = append(e.result.Objects, services...)
+	e.result.Objects = append(e.result.Objects, deployments...)
+
+	return e.result, nil
+}
+
+func (e *exporter) unsupported(service, key, reason string, args ...interface{}) {
+	e.result.Unsupported[service] = append(e.result.Unsupported[service], Unsupported{
+		Key:    key,
+		Reason: fmt.Sprintf(reason, args...),
+	})
+}
+
+func (e *exporter) selector(name string) map[string]string {
+	return map[string]string{
+		labels.PROJECT.Str(): e.project.Name,
+		labels.SERVICE.Str(): name,
+	}
+}
+
+func (e *exporter) exportService(name string, c *config.ServiceConfig) (*Deployment, *Service, []Object, error) {
+	e.reportUnhandledKeys(name, c)
+
+	objectName := dnsName(name)
+	selector := e.selector(name)
+
+	container := Container{
+		Name:       objectName,
+		Image:      c.Image,
+		Command:    []string(c.Entrypoint),
+		Args:       []string(c.Command),
+		WorkingDir: c.WorkingDir,
+		Stdin:      c.StdinOpen,
+		TTY:        c.Tty,
+	}
+	if container.Image == "" {
+		container.Image = e.project.Name + "_" + name
+		e.unsupported(name, "build", "the image is not built, %s must be pushed to a registry the cluster can pull from", container.Image)
+	}
+
+	configMaps, err := e.environment(name, c, &container)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	service, err := e.ports(name, c, &container)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	container.Resources = resources(c)
+	container.SecurityContext = e.securityContext(name, c)
+	container.LivenessProbe = probe(c.HealthCheck)
+	container.ReadinessProbe = probe(c.HealthCheck)
+
+	podSpec := PodSpec{
+		Hostname: c.Hostname,
+	}
+	podSpec.Volumes, container.VolumeMounts = e.volumes(name, c)
+	podSpec.HostAliases = e.hostAliases(name, c)
+	podSpec.TerminationGracePeriodSeconds = durationSeconds(c.StopGracePeriod)
+
+	switch c.Restart {
+	case "", "always", "unless-stopped":
+	default:
+		e.unsupported(name, "restart", "deployments always restart their pods, %q is not translated", c.Restart)
+	}
+
+	switch {
+	case c.NetworkMode == "host":
+		podSpec.HostNetwork = true
+	case c.NetworkMode != "" && c.NetworkMode != "bridge" && c.NetworkMode != "default":
+		e.unsupported(name, "network_mode", "%q is not translated, the pod uses the cluster network", c.NetworkMode)
+	}
+	if c.Pid == "host" {
+		podSpec.HostPID = true
+	} else if c.Pid != "" {
+		e.unsupported(name, "pid", "%q is not translated", c.Pid)
+	}
+	if c.Ipc == "host" {
+		podSpec.HostIPC = true
+	} else if c.Ipc != "" {
+		e.unsupported(name, "ipc", "%q is not translated", c.Ipc)
+	}
+	if c.Networks != nil {
+		for _, network := range c.Networks.Networks {
+			if network.Name != "default" || len(network.Aliases) > 0 || network.IPv4Address != "" || network.IPv6Address != "" {
+				e.unsupported(name, "networks", "pods share a flat network, network %s is not translated", network.Name)
+			}
+		}
+	}
+
+	podSpec.Containers = []Container{container}
+
+	deployment := &Deployment{
+		TypeMeta: TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		Metadata: ObjectMeta{
+			Name:   objectName,
+			Labels: selector,
+		},
+		Spec: DeploymentSpec{
+			Replicas: 1,
+			Selector: LabelSelector{MatchLabels: selector},
+			Template: PodTemplateSpec{
+				Metadata: ObjectMeta{
+					Labels:      selector,
+					Annotations: map[string]string(c.Labels),
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+
+	return deployment, service, configMaps, nil
+}
+
+// reportUnhandledKeys reports every key set on the service that the
+// exporter doesn't know how to translate.
+func (e *exporter) reportUnhandledKeys(name string, c *config.ServiceConfig) {
+	value := reflect.ValueOf(c).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if handledKeys[key] {
+			continue
+		}
+		fieldValue := value.Field(i)
+		if reflect.DeepEqual(fieldValue.Interface(), reflect.Zero(field.Type).Interface()) {
+			continue
+		}
+		if fieldValue.Kind() == reflect.Slice && fieldValue.Len() == 0 {
+			continue
+		}
+		e.unsupported(name, key, "no Kubernetes equivalent")
+	}
+}
+
+func (e *exporter) environment(name string, c *config.ServiceConfig, container *Container) ([]Object, error) {
+	configMaps := []Object{}
+	fromFiles := map[string]string{}
+	for i, envFile := range c.EnvFile {
+		relativeTo := ""
+		if len(e.project.Files) > 0 {
+			relativeTo = e.project.Files[0]
+		}
+		content, _, err := e.resourceLookup.Lookup(envFile, relativeTo)
+		if err != nil {
+			return nil, err
+		}
+		data := parseEnvFile(content)
+		for k, v := range data {
+			if _, ok := fromFiles[k]; !ok {
+				fromFiles[k] = v
+			}
+		}
+		configMapName := fmt.Sprintf("%s-env-%d", dnsName(name), i)
+		configMaps = append(configMaps, &ConfigMap{
+			TypeMeta: TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			Metadata: ObjectMeta{
+				Name:        configMapName,
+				Labels:      e.selector(name),
+				Annotations: map[string]string{"com.docker.compose.env-file": envFile},
+			},
+			Data: data,
+		})
+		container.EnvFrom = append(container.EnvFrom, EnvFromSource{
+			ConfigMapRef: LocalObjectReference{Name: configMapName},
+		})
+	}
+
+	// env_file values are already merged into environment, keep only the
+	// variables that don't come from the ConfigMaps.
+	for _, env := range c.Environment {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) == 1 {
+			e.unsupported(name, "environment", "%s has no value, it is taken from the host environment by compose", parts[0])
+			continue
+		}
+		if value, ok := fromFiles[parts[0]]; ok && value == parts[1] {
+			continue
+		}
+		container.Env = append(container.Env, EnvVar{Name: parts[0], Value: parts[1]})
+	}
+	// A mapping environment comes in random order, keep manifests stable
+	sort.Slice(container.Env, func(i, j int) bool {
+		return container.Env[i].Name < container.Env[j].Name
+	})
+	return configMaps, nil
+}
+
+func parseEnvFile(content []byte) map[string]string {
+	data := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewBuffer(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 {
+			data[parts[0]] = parts[1]
+		}
+	}
+	return data
+}
+
+func (e *exporter) ports(name string, c *config.ServiceConfig, container *Container) (*Service, error) {
+	specs := append(append([]string{}, c.Ports...), c.Expose...)
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	exposed, bindings, err := nat.ParsePortSpecs(specs)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := []string{}
+	for port := range exposed {
+		ports = append(ports, string(port))
+	}
+	sort.Strings(ports)
+
+	service := &Service{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "Service"},
+		Metadata: ObjectMeta{
+			Name:   dnsName(name),
+			Labels: e.selector(name),
+		},
+		Spec: ServiceSpec{
+			Selector: e.selector(name),
+		},
+	}
+	for _, p := range ports {
+		port := nat.Port(p)
+		protocol := strings.ToUpper(port.Proto())
+		container.Ports = append(container.Ports, ContainerPort{
+			ContainerPort: port.Int(),
+			Protocol:      protocol,
+		})
+
+		servicePort := port.Int()
+		for _, binding := range bindings[port] {
+			if binding.HostIP != "" {
+				e.unsupported(name, "ports", "binding %s to host IP %s is not translated", port, binding.HostIP)
+			}
+			if hostPort, err := strconv.Atoi(binding.HostPort); err == nil {
+				servicePort = hostPort
+			}
+		}
+		service.Spec.Ports = append(service.Spec.Ports, ServicePort{
+			Name:       fmt.Sprintf("%d-%s", servicePort, port.Proto()),
+			Port:       servicePort,
+			TargetPort: port.Int(),
+			Protocol:   protocol,
+		})
+	}
+
+	return service, nil
+}
+
+func (e *exporter) volumes(name string, c *config.ServiceConfig) ([]Volume, []VolumeMount) {
+	volumes := []Volume{}
+	mounts := []VolumeMount{}
+	seen := map[string]bool{}
+
+	if c.Volumes != nil {
+		for i, v := range c.Volumes.Volumes {
+			volume := Volume{Name: fmt.Sprintf("%s-%d", dnsName(name), i)}
+			switch {
+			case v.Source == "":
+				volume.EmptyDir = &EmptyDirVolumeSource{}
+			case project.IsNamedVolume(v.Source):
+				claim, ok := e.claims[v.Source]
+				if !ok {
+					claim = dnsName(v.Source)
+					e.unsupported(name, "volumes", "volume %s is not declared, a claim is generated for it", v.Source)
+				}
+				if volumeConfig := e.volumeConfigs[v.Source]; volumeConfig != nil {
+					if volumeConfig.External.External {
+						volume.Name = claim
+						volume.PersistentVolumeClaim = &PersistentVolumeClaimVolumeSource{ClaimName: claim}
+						break
+					}
+					if volumeConfig.Driver != "" || len(volumeConfig.DriverOpts) > 0 {
+						e.unsupported(name, "volumes", "driver of volume %s is not translated, the default storage class is used", v.Source)
+					}
+				}
+				e.claim(claim)
+				volume.Name = claim
+				volume.PersistentVolumeClaim = &PersistentVolumeClaimVolumeSource{ClaimName: claim}
+			default:
+				source := v.Source
+				if !filepath.IsAbs(source) && len(e.project.Files) > 0 {
+					source = strings.SplitN(e.resourceLookup.ResolvePath(v.String(), e.project.Files[0]), ":", 2)[0]
+				}
+				volume.HostPath = &HostPathVolumeSource{Path: source}
+				e.unsupported(name, "volumes", "bind mount of %s is translated to a hostPath volume, it must exist on the node", source)
+			}
+			// A claim mounted several times is declared once in the pod
+			if !seen[volume.Name] {
+				volumes = append(volumes, volume)
+				seen[volume.Name] = true
+			}
+			mounts = append(mounts, VolumeMount{
+				Name:      volume.Name,
+				MountPath: v.Destination,
+				ReadOnly:  v.AccessMode == "ro",
+			})
+		}
+	}
+
+	for i, tmpfs := range c.Tmpfs {
+		volume := Volume{
+			Name:     fmt.Sprintf("%s-tmpfs-%d", dnsName(name), i),
+			EmptyDir: &EmptyDirVolumeSource{Medium: "Memory"},
+		}
+		volumes = append(volumes, volume)
+		mounts = append(mounts, VolumeMount{
+			Name:      volume.Name,
+			MountPath: strings.SplitN(tmpfs, ":", 2)[0],
+		})
+	}
+
+	return volumes, mounts
+}
+
+// claim generates the PersistentVolumeClaim of a named volume.
+func (e *exporter) claim(claim string) {
+	if _, ok := e.generatedClaims[claim]; ok {
+		return
+	}
+	e.generatedClaims[claim] = &PersistentVolumeClaim{
+		TypeMeta: TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+		Metadata: ObjectMeta{
+			Name:   claim,
+			Labels: map[string]string{labels.PROJECT.Str(): e.project.Name},
+		},
+		Spec: PersistentVolumeClaimSpec{
+			AccessModes: []string{"ReadWriteOnce"},
+			Resources: ResourceRequirements{
+				Requests: map[string]string{"storage": DefaultVolumeSize},
+			},
+		},
+	}
+}
+
+func (e *exporter) hostAliases(name string, c *config.ServiceConfig) []HostAlias {
+	aliases := []HostAlias{}
+	for _, extraHost := range c.ExtraHosts {
+		parts := strings.SplitN(extraHost, ":", 2)
+		if len(parts) != 2 {
+			e.unsupported(name, "extra_hosts", "invalid entry %s", extraHost)
+			continue
+		}
+		aliases = append(aliases, HostAlias{IP: parts[1], Hostnames: []string{parts[0]}})
+	}
+	if len(aliases) == 0 {
+		return nil
+	}
+	return aliases
+}
+
+func (e *exporter) securityContext(name string, c *config.ServiceConfig) *SecurityContext {
+	securityContext := &SecurityContext{
+		Privileged:             c.Privileged,
+		ReadOnlyRootFilesystem: c.ReadOnly,
+	}
+	if len(c.CapAdd) > 0 || len(c.CapDrop) > 0 {
+		securityContext.Capabilities = &Capabilities{
+			Add:  c.CapAdd,
+			Drop: c.CapDrop,
+		}
+	}
+	if c.User != "" {
+		uid, err := strconv.ParseInt(strings.SplitN(c.User, ":", 2)[0], 10, 64)
+		if err == nil {
+			securityContext.RunAsUser = &uid
+		} else {
+			e.unsupported(name, "user", "only numeric user IDs can be translated, got %s", c.User)
+		}
+	}
+	if reflect.DeepEqual(*securityContext, SecurityContext{}) {
+		return nil
+	}
+	return securityContext
+}
+
+func resources(c *config.ServiceConfig) *ResourceRequirements {
+	limits := map[string]string{}
+	requests := map[string]string{}
+	if c.MemLimit > 0 {
+		limits["memory"] = strconv.FormatInt(int64(c.MemLimit), 10)
+	}
+	if c.MemReservation > 0 {
+		requests["memory"] = strconv.FormatInt(int64(c.MemReservation), 10)
+	}
+	// cpu_shares are relative to 1024 shares per CPU, cpu_quota to the
+	// default 100ms CFS period.
+	if c.CPUShares > 0 {
+		requests["cpu"] = fmt.Sprintf("%dm", int64(c.CPUShares)*1000/1024)
+	}
+	if c.CPUQuota > 0 {
+		limits["cpu"] = fmt.Sprintf("%dm", int64(c.CPUQuota)/100)
+	}
+	if len(limits) == 0 && len(requests) == 0 {
+		return nil
+	}
+	resources := &ResourceRequirements{}
+	if len(limits) > 0 {
+		resources.Limits = limits
+	}
+	if len(requests) > 0 {
+		resources.Requests = requests
+	}
+	return resources
+}
+
+func probe(healthCheck config.HealthCheck) *Probe {
+	test := healthCheck.TestCommand()
+	if len(test) < 2 {
+		return nil
+	}
+	probe := &Probe{
+		FailureThreshold: healthCheck.Retries,
+	}
+	switch test[0] {
+	case "CMD":
+		probe.Exec.Command = test[1:]
+	case "CMD-SHELL":
+		probe.Exec.Command = []string{"/bin/sh", "-c", test[1]}
+	default:
+		return nil
+	}
+	if seconds := durationSeconds(healthCheck.Interval); seconds != nil {
+		probe.PeriodSeconds = *seconds
+	}
+	if seconds := durationSeconds(healthCheck.Timeout); seconds != nil {
+		probe.TimeoutSeconds = *seconds
+	}
+	return probe
+}
+
+func durationSeconds(s string) *int {
+	if s == "" {
+		return nil
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return nil
+	}
+	seconds := int(duration.Seconds())
+	return &seconds
+}
+
+// dnsName turns a compose name into a valid Kubernetes object name.
+func dnsName(name string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
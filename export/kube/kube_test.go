@@ -0,0 +1,79 @@
+package kube
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/docker/libcompose/project"
+	"github.com/stretchr/testify/assert"
+)
+
+func exportFile(t *testing.T, file string) *Result {
+	content, err := ioutil.ReadFile(file)
+	assert.Nil(t, err)
+
+	p := project.NewProject(&project.Context{
+		ComposeFiles: []string{file},
+		ComposeBytes: [][]byte{content},
+		ProjectName:  "myapp",
+	}, nil, nil)
+	assert.Nil(t, p.Parse())
+
+	result, err := Export(p)
+	assert.Nil(t, err)
+	return result
+}
+
+func TestExport(t *testing.T) {
+	result := exportFile(t, "testdata/docker-compose.yml")
+
+	kinds := []string{}
+	names := []string{}
+	for _, object := range result.Objects {
+		typeMeta, objectMeta := object.Meta()
+		kinds = append(kinds, typeMeta.Kind)
+		names = append(names, objectMeta.Name)
+	}
+	assert.Equal(t, []string{"PersistentVolumeClaim", "ConfigMap", "Service", "Service", "Deployment", "Deployment"}, kinds)
+	assert.Equal(t, []string{"data", "web-app-env-0", "db", "web-app", "db", "web-app"}, names)
+
+	web := result.Objects[5].(*Deployment)
+	container := web.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "nginx:1.11", container.Image)
+	assert.Equal(t, []string{"nginx", "-g", "daemon off;"}, container.Args)
+	assert.Equal(t, []EnvFromSource{{ConfigMapRef: LocalObjectReference{Name: "web-app-env-0"}}}, container.EnvFrom)
+	assert.Equal(t, []EnvVar{{Name: "DEBUG", Value: "false"}, {Name: "WORKERS", Value: "8"}}, container.Env)
+	assert.Equal(t, []ContainerPort{{ContainerPort: 80, Protocol: "TCP"}}, container.Ports)
+	assert.Equal(t, "67108864", container.Resources.Limits["memory"])
+	assert.Equal(t, []string{"/bin/sh", "-c", "curl -f http://localhost"}, container.LivenessProbe.Exec.Command)
+	assert.Equal(t, 30, container.LivenessProbe.PeriodSeconds)
+	assert.Equal(t, 5, container.ReadinessProbe.TimeoutSeconds)
+	assert.Equal(t, 3, container.ReadinessProbe.FailureThreshold)
+	assert.Equal(t, "data", web.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.NotNil(t, web.Spec.Template.Spec.Volumes[1].HostPath)
+	assert.True(t, container.VolumeMounts[1].ReadOnly)
+
+	webService := result.Objects[3].(*Service)
+	assert.Equal(t, []ServicePort{{Name: "8080-tcp", Port: 8080, TargetPort: 80, Protocol: "TCP"}}, webService.Spec.Ports)
+
+	db := result.Objects[4].(*Deployment)
+	assert.Equal(t, "shared-db", db.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, int64(999), *db.Spec.Template.Spec.Containers[0].SecurityContext.RunAsUser)
+
+	webKeys := []string{}
+	for _, unsupported := range result.Unsupported["web_app"] {
+		webKeys = append(webKeys, unsupported.Key)
+	}
+	assert.Equal(t, []string{"depends_on", "volumes", "restart"}, webKeys)
+	assert.Equal(t, []Unsupported{{Key: "devices", Reason: "no Kubernetes equivalent"}}, result.Unsupported["db"])
+}
+
+func TestWrite(t *testing.T) {
+	result := exportFile(t, "testdata/docker-compose.yml")
+
+	var buf bytes.Buffer
+	assert.Nil(t, result.Write(&buf))
+	assert.Contains(t, buf.String(), "apiVersion: apps/v1\nkind: Deployment\n")
+	assert.Equal(t, len(result.Objects)-1, bytes.Count(buf.Bytes(), []byte("\n---\n")))
+}
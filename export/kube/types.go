@@ -0,0 +1,232 @@
+package kube
+
+// The types below mirror the subset of the Kubernetes API objects the
+// exporter produces, so that libcompose doesn't depend on the Kubernetes
+// client libraries.
+
+// TypeMeta holds the kind and API version of a manifest.
+type TypeMeta struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+}
+
+// ObjectMeta holds the name, labels and annotations of a manifest.
+type ObjectMeta struct {
+	Name        string            `yaml:"name,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Object is implemented by every manifest produced by the exporter.
+type Object interface {
+	Meta() (TypeMeta, ObjectMeta)
+}
+
+// Deployment is an apps/v1 Deployment.
+type Deployment struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta     `yaml:"metadata"`
+	Spec     DeploymentSpec `yaml:"spec"`
+}
+
+// Meta implements Object.
+func (d *Deployment) Meta() (TypeMeta, ObjectMeta) {
+	return d.TypeMeta, d.Metadata
+}
+
+// DeploymentSpec holds the desired state of a Deployment.
+type DeploymentSpec struct {
+	Replicas int             `yaml:"replicas"`
+	Selector LabelSelector   `yaml:"selector"`
+	Template PodTemplateSpec `yaml:"template"`
+}
+
+// LabelSelector selects objects by labels.
+type LabelSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels"`
+}
+
+// PodTemplateSpec describes the pods created by a Deployment.
+type PodTemplateSpec struct {
+	Metadata ObjectMeta `yaml:"metadata"`
+	Spec     PodSpec    `yaml:"spec"`
+}
+
+// PodSpec describes a pod.
+type PodSpec struct {
+	Containers                    []Container `yaml:"containers"`
+	Volumes                       []Volume    `yaml:"volumes,omitempty"`
+	RestartPolicy                 string      `yaml:"restartPolicy,omitempty"`
+	TerminationGracePeriodSeconds *int        `yaml:"terminationGracePeriodSeconds,omitempty"`
+	Hostname                      string      `yaml:"hostname,omitempty"`
+	HostNetwork                   bool        `yaml:"hostNetwork,omitempty"`
+	HostPID                       bool        `yaml:"hostPID,omitempty"`
+	HostIPC                       bool        `yaml:"hostIPC,omitempty"`
+	HostAliases                   []HostAlias `yaml:"hostAliases,omitempty"`
+}
+
+// HostAlias adds an entry to the /etc/hosts file of a pod.
+type HostAlias struct {
+	IP        string   `yaml:"ip"`
+	Hostnames []string `yaml:"hostnames"`
+}
+
+// Container describes a container of a pod.
+type Container struct {
+	Name            string                `yaml:"name"`
+	Image           string                `yaml:"image,omitempty"`
+	Command         []string              `yaml:"command,omitempty"`
+	Args            []string              `yaml:"args,omitempty"`
+	WorkingDir      string                `yaml:"workingDir,omitempty"`
+	Ports           []ContainerPort       `yaml:"ports,omitempty"`
+	EnvFrom         []EnvFromSource       `yaml:"envFrom,omitempty"`
+	Env             []EnvVar              `yaml:"env,omitempty"`
+	Resources       *ResourceRequirements `yaml:"resources,omitempty"`
+	VolumeMounts    []VolumeMount         `yaml:"volumeMounts,omitempty"`
+	LivenessProbe   *Probe                `yaml:"livenessProbe,omitempty"`
+	ReadinessProbe  *Probe                `yaml:"readinessProbe,omitempty"`
+	SecurityContext *SecurityContext      `yaml:"securityContext,omitempty"`
+	Stdin           bool                  `yaml:"stdin,omitempty"`
+	TTY             bool                  `yaml:"tty,omitempty"`
+}
+
+// ContainerPort is a port exposed by a container.
+type ContainerPort struct {
+	ContainerPort int    `yaml:"containerPort"`
+	Protocol      string `yaml:"protocol,omitempty"`
+}
+
+// EnvVar is an environment variable of a container.
+type EnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// EnvFromSource loads environment variables from a ConfigMap.
+type EnvFromSource struct {
+	ConfigMapRef LocalObjectReference `yaml:"configMapRef"`
+}
+
+// LocalObjectReference references an object of the same namespace.
+type LocalObjectReference struct {
+	Name string `yaml:"name"`
+}
+
+// ResourceRequirements holds the resource limits and requests of a container.
+type ResourceRequirements struct {
+	Limits   map[string]string `yaml:"limits,omitempty"`
+	Requests map[string]string `yaml:"requests,omitempty"`
+}
+
+// VolumeMount mounts a pod volume in a container.
+type VolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+// Volume is a volume of a pod.
+type Volume struct {
+	Name                  string                             `yaml:"name"`
+	PersistentVolumeClaim *PersistentVolumeClaimVolumeSource `yaml:"persistentVolumeClaim,omitempty"`
+	HostPath              *HostPathVolumeSource              `yaml:"hostPath,omitempty"`
+	EmptyDir              *EmptyDirVolumeSource              `yaml:"emptyDir,omitempty"`
+}
+
+// PersistentVolumeClaimVolumeSource references a PersistentVolumeClaim.
+type PersistentVolumeClaimVolumeSource struct {
+	ClaimName string `yaml:"claimName"`
+}
+
+// HostPathVolumeSource mounts a path of the node.
+type HostPathVolumeSource struct {
+	Path string `yaml:"path"`
+}
+
+// EmptyDirVolumeSource is a scratch volume that lives as long as the pod.
+type EmptyDirVolumeSource struct {
+	Medium string `yaml:"medium,omitempty"`
+}
+
+// Probe checks the health of a container.
+type Probe struct {
+	Exec             ExecAction `yaml:"exec"`
+	PeriodSeconds    int        `yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds   int        `yaml:"timeoutSeconds,omitempty"`
+	FailureThreshold int        `yaml:"failureThreshold,omitempty"`
+}
+
+// ExecAction runs a command in a container.
+type ExecAction struct {
+	Command []string `yaml:"command"`
+}
+
+// SecurityContext holds the security options of a container.
+type SecurityContext struct {
+	Privileged             bool          `yaml:"privileged,omitempty"`
+	ReadOnlyRootFilesystem bool          `yaml:"readOnlyRootFilesystem,omitempty"`
+	RunAsUser              *int64        `yaml:"runAsUser,omitempty"`
+	Capabilities           *Capabilities `yaml:"capabilities,omitempty"`
+}
+
+// Capabilities holds the capabilities added to or dropped from a container.
+type Capabilities struct {
+	Add  []string `yaml:"add,omitempty"`
+	Drop []string `yaml:"drop,omitempty"`
+}
+
+// Service is a v1 Service.
+type Service struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta  `yaml:"metadata"`
+	Spec     ServiceSpec `yaml:"spec"`
+}
+
+// Meta implements Object.
+func (s *Service) Meta() (TypeMeta, ObjectMeta) {
+	return s.TypeMeta, s.Metadata
+}
+
+// ServiceSpec holds the desired state of a Service.
+type ServiceSpec struct {
+	Selector map[string]string `yaml:"selector"`
+	Ports    []ServicePort     `yaml:"ports"`
+}
+
+// ServicePort is a port exposed by a Service.
+type ServicePort struct {
+	Name       string `yaml:"name"`
+	Port       int    `yaml:"port"`
+	TargetPort int    `yaml:"targetPort"`
+	Protocol   string `yaml:"protocol,omitempty"`
+}
+
+// ConfigMap is a v1 ConfigMap.
+type ConfigMap struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta        `yaml:"metadata"`
+	Data     map[string]string `yaml:"data"`
+}
+
+// Meta implements Object.
+func (c *ConfigMap) Meta() (TypeMeta, ObjectMeta) {
+	return c.TypeMeta, c.Metadata
+}
+
+// PersistentVolumeClaim is a v1 PersistentVolumeClaim.
+type PersistentVolumeClaim struct {
+	TypeMeta `yaml:",inline"`
+	Metadata ObjectMeta                `yaml:"metadata"`
+	Spec     PersistentVolumeClaimSpec `yaml:"spec"`
+}
+
+// Meta implements Object.
+func (p *PersistentVolumeClaim) Meta() (TypeMeta, ObjectMeta) {
+	return p.TypeMeta, p.Metadata
+}
+
+// PersistentVolumeClaimSpec holds the desired state of a PersistentVolumeClaim.
+type PersistentVolumeClaimSpec struct {
+	AccessModes []string             `yaml:"accessModes"`
+	Resources   ResourceRequirements `yaml:"resources"`
+}
@@ -0,0 +1,265 @@
+// Package systemd exports libcompose projects as systemd units.
+package systemd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/libcompose/project"
+)
+
+// DefaultBinary is the libcompose binary invoked by the units.
+const DefaultBinary = "libcompose-cli"
+
+// defaultStopTimeout is the seconds the engine waits for a container to stop
+// before killing it.
+const defaultStopTimeout = 10
+
+// Options holds the settings of the export.
+type Options struct {
+	// Binary is the libcompose binary invoked by the units, DefaultBinary
+	// if empty. It should be an absolute path.
+	Binary string
+	// WantedBy is the target that starts the project target,
+	// multi-user.target if empty.
+	WantedBy string
+}
+
+// Unit is a systemd unit file.
+type Unit struct {
+	Name    string
+	Content []byte
+}
+
+// Unsupported describes a service key that couldn't be translated exactly.
+type Unsupported struct {
+	Key    string
+	Reason string
+}
+
+func (u Unsupported) String() string {
+	return u.Key + ": " + u.Reason
+}
+
+// Result holds the units exported from a project.
+type Result struct {
+	// Units holds a service unit per service, sorted by name, followed by
+	// the target unit of the project.
+	Units []Unit
+	// Unsupported lists, per service name, what couldn't be translated.
+	Unsupported map[string][]Unsupported
+}
+
+// Write writes the units to w, each preceded by a comment with its name.
+func (r *Result) Write(w io.Writer) error {
+	for i, unit := range r.Units {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# %s\n", unit.Name); err != nil {
+			return err
+		}
+		if _, err := w.Write(unit.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteDir writes each unit to its own file in dir, created if needed.
+func (r *Result) WriteDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, unit := range r.Units {
+		if err := ioutil.WriteFile(filepath.Join(dir, unit.Name), unit.Content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TargetName returns the name of the target unit of a project.
+func TargetName(projectName string) string {
+	return projectName + ".target"
+}
+
+// ServiceName returns the name of the unit of a service.
+func ServiceName(projectName, service string) string {
+	return projectName + "-" + service + ".service"
+}
+
+// Export generates a unit per service of a parsed project, which runs
+// libcompose up and stop for this service only, and a target unit that
+// groups them. Up leaves the services a service depends on, as returned by
+// Service.DependentServices, alone: their units are started before it. The
+// restart policy of a service is left to the engine, so the units don't
+// restart. The units pass the compose, values and overlay files of the
+// project, values set otherwise can't be exported.
+func Export(p *project.Project, options Options) (*Result, error) {
+	if options.Binary == "" {
+		options.Binary = DefaultBinary
+	}
+	if options.WantedBy == "" {
+		options.WantedBy = "multi-user.target"
+	}
+	if len(p.Values()) > 0 {
+		return nil, fmt.Errorf("Values that are not set by a values file can't be passed to the units")
+	}
+	files, err := absFiles(p.Files)
+	if err != nil {
+		return nil, err
+	}
+	command := []string{options.Binary, "--project-name", p.Name}
+	for _, file := range files {
+		command = append(command, "--file", file)
+	}
+	valuesFiles, err := absFiles(p.ValuesFiles())
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range valuesFiles {
+		command = append(command, "--values", file)
+	}
+	overlayFiles, err := absFiles(p.OverlayFiles())
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range overlayFiles {
+		command = append(command, "--overlay", file)
+	}
+
+	result := &Result{Unsupported: map[string][]Unsupported{}}
+	names := p.ServiceConfigs.Keys()
+	sort.Strings(names)
+
+	for _, name := range names {
+		service, err := p.CreateService(name)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to export service %s: %v", name, err)
+		}
+		unit, unsupported := serviceUnit(p, files, command, name, service, options)
+		result.Units = append(result.Units, unit)
+		if len(unsupported) > 0 {
+			result.Unsupported[name] = unsupported
+		}
+	}
+
+	var wants []string
+	for _, name := range names {
+		wants = append(wants, ServiceName(p.Name, name))
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "[Unit]\n")
+	fmt.Fprintf(&buf, "Description=%s compose project\n", p.Name)
+	if len(wants) > 0 {
+		fmt.Fprintf(&buf, "Wants=%s\n", strings.Join(wants, " "))
+	}
+	fmt.Fprintf(&buf, "\n[Install]\n")
+	fmt.Fprintf(&buf, "WantedBy=%s\n", options.WantedBy)
+	result.Units = append(result.Units, Unit{Name: TargetName(p.Name), Content: buf.Bytes()})
+
+	return result, nil
+}
+
+// absFiles returns the absolute paths of files.
+func absFiles(files []string) ([]string, error) {
+	result := make([]string, 0, len(files))
+	for _, file := range files {
+		absFile, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, absFile)
+	}
+	return result, nil
+}
+
+func serviceUnit(p *project.Project, files, command []string, name string, service project.Service, options Options) (Unit, []Unsupported) {
+	var unsupported []Unsupported
+	serviceConfig := service.Config()
+
+	after := []string{"docker.service"}
+	requires := []string{"docker.service"}
+	var wants []string
+	seen := map[string]bool{}
+	for _, relationship := range service.DependentServices() {
+		if relationship.Target == name || seen[relationship.Target] || !p.ServiceConfigs.Has(relationship.Target) {
+			continue
+		}
+		seen[relationship.Target] = true
+		unit := ServiceName(p.Name, relationship.Target)
+		after = append(after, unit)
+		if relationship.Optional {
+			wants = append(wants, unit)
+		} else {
+			requires = append(requires, unit)
+		}
+	}
+	sort.Strings(after[1:])
+	sort.Strings(requires[1:])
+	sort.Strings(wants)
+
+	stopTimeout := defaultStopTimeout
+	if serviceConfig.StopGracePeriod != "" {
+		if duration, err := time.ParseDuration(serviceConfig.StopGracePeriod); err == nil {
+			stopTimeout = int(duration.Seconds())
+		} else {
+			unsupported = append(unsupported, Unsupported{Key: "stop_grace_period", Reason: err.Error()})
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "[Unit]\n")
+	fmt.Fprintf(&buf, "Description=%s service of the %s compose project\n", name, p.Name)
+	fmt.Fprintf(&buf, "PartOf=%s\n", TargetName(p.Name))
+	fmt.Fprintf(&buf, "After=%s\n", strings.Join(after, " "))
+	fmt.Fprintf(&buf, "Requires=%s\n", strings.Join(requires, " "))
+	if len(wants) > 0 {
+		fmt.Fprintf(&buf, "Wants=%s\n", strings.Join(wants, " "))
+	}
+	fmt.Fprintf(&buf, "\n[Service]\n")
+	if len(files) > 0 {
+		fmt.Fprintf(&buf, "WorkingDirectory=%s\n", escape(filepath.Dir(files[0])))
+	}
+	start := append(append([]string{}, command...), "up", name)
+	stop := append(append([]string{}, command...), "stop", "--timeout", strconv.Itoa(stopTimeout), name)
+	fmt.Fprintf(&buf, "ExecStart=%s\n", commandLine(start))
+	fmt.Fprintf(&buf, "ExecStop=%s\n", commandLine(stop))
+	fmt.Fprintf(&buf, "TimeoutStopSec=%d\n", stopTimeout+30)
+	// The engine restarts the containers as the compose file says, systemd
+	// restarting up as well would compete with it
+	fmt.Fprintf(&buf, "Restart=no\n")
+	fmt.Fprintf(&buf, "\n[Install]\n")
+	fmt.Fprintf(&buf, "WantedBy=%s\n", TargetName(p.Name))
+
+	return Unit{Name: ServiceName(p.Name, name), Content: buf.Bytes()}, unsupported
+}
+
+// commandLine quotes the arguments of a command for ExecStart and ExecStop.
+func commandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		arg = escape(arg)
+		if arg == "" || strings.ContainsAny(arg, " \t\"'\\;") {
+			arg = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// escape escapes the specifiers and variables systemd expands.
+func escape(s string) string {
+	return strings.NewReplacer("%", "%%", "$", "$$").Replace(s)
+}
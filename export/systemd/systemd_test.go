@@ -0,0 +1,205 @@
+package systemd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/project"
+	"github.com/docker/libcompose/project/options"
+	"github.com/stretchr/testify/assert"
+)
+
+type testService struct {
+	project.EmptyService
+	name   string
+	config *config.ServiceConfig
+	up     *[]string
+}
+
+func (s *testService) Up(ctx context.Context, options options.Up) error {
+	if s.up != nil {
+		*s.up = append(*s.up, s.name)
+	}
+	return nil
+}
+
+func (s *testService) Config() *config.ServiceConfig {
+	return s.config
+}
+
+func (s *testService) DependentServices() []project.ServiceRelationship {
+	return project.DefaultDependentServices(nil, s)
+}
+
+// testServiceFactory records in up the services brought up, if not nil.
+type testServiceFactory struct {
+	up *[]string
+}
+
+func (f testServiceFactory) Create(p *project.Project, name string, serviceConfig *config.ServiceConfig) (project.Service, error) {
+	return &testService{name: name, config: serviceConfig, up: f.up}, nil
+}
+
+func exportFile(t *testing.T, file string) *Result {
+	content, err := ioutil.ReadFile(file)
+	assert.Nil(t, err)
+
+	p := project.NewProject(&project.Context{
+		ComposeFiles:   []string{file},
+		ComposeBytes:   [][]byte{content},
+		ProjectName:    "myapp",
+		ServiceFactory: testServiceFactory{},
+	}, nil, nil)
+	assert.Nil(t, p.Parse())
+
+	result, err := Export(p, Options{Binary: "/usr/bin/libcompose-cli"})
+	assert.Nil(t, err)
+	return result
+}
+
+func TestExport(t *testing.T) {
+	result := exportFile(t, "testdata/docker-compose.yml")
+
+	var names []string
+	units := map[string]string{}
+	for _, unit := range result.Units {
+		names = append(names, unit.Name)
+		units[unit.Name] = string(unit.Content)
+	}
+	assert.Equal(t, []string{"myapp-app.service", "myapp-data.service", "myapp-db.service", "myapp-web.service", "myapp.target"}, names)
+
+	file, err := filepath.Abs("testdata/docker-compose.yml")
+	assert.Nil(t, err)
+	assert.Equal(t, `[Unit]
+Description=app service of the myapp compose project
+PartOf=myapp.target
+After=docker.service myapp-data.service myapp-db.service
+Requires=docker.service myapp-data.service myapp-db.service
+
+[Service]
+WorkingDirectory=`+filepath.Dir(file)+`
+ExecStart=/usr/bin/libcompose-cli --project-name myapp --file `+file+` up app
+ExecStop=/usr/bin/libcompose-cli --project-name myapp --file `+file+` stop --timeout 10 app
+TimeoutStopSec=40
+Restart=no
+
+[Install]
+WantedBy=myapp.target
+`, units["myapp-app.service"])
+
+	web := units["myapp-web.service"]
+	assert.Contains(t, web, "\nRequires=docker.service myapp-app.service\n")
+	assert.Contains(t, web, "stop --timeout 60 web\n")
+	assert.Contains(t, web, "\nTimeoutStopSec=90\n")
+	assert.Contains(t, web, "\nRestart=no\n")
+	assert.Contains(t, units["myapp-db.service"], "\nRequires=docker.service\n")
+
+	assert.Equal(t, `[Unit]
+Description=myapp compose project
+Wants=myapp-app.service myapp-data.service myapp-db.service myapp-web.service
+
+[Install]
+WantedBy=multi-user.target
+`, units["myapp.target"])
+
+	assert.Empty(t, result.Unsupported)
+}
+
+func TestExportUpLeavesDependencies(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/docker-compose.yml")
+	assert.Nil(t, err)
+	var up []string
+	p := project.NewProject(&project.Context{
+		ComposeFiles:   []string{"testdata/docker-compose.yml"},
+		ComposeBytes:   [][]byte{content},
+		ProjectName:    "myapp",
+		ServiceFactory: testServiceFactory{up: &up},
+	}, nil, nil)
+	assert.Nil(t, p.Parse())
+
+	// The unit of app runs up app, the units of db and data start them
+	assert.Nil(t, p.Up(context.Background(), options.Up{}, "app"))
+	assert.Equal(t, []string{"app"}, up)
+}
+
+func TestExportValuesAndOverlays(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/docker-compose.yml")
+	assert.Nil(t, err)
+	p := project.NewProject(&project.Context{
+		ComposeFiles:   []string{"testdata/docker-compose.yml"},
+		ComposeBytes:   [][]byte{content},
+		ValuesFiles:    []string{"testdata/values.yml"},
+		OverlayFiles:   []string{"testdata/overlay.yml"},
+		ProjectName:    "myapp",
+		ServiceFactory: testServiceFactory{},
+	}, nil, nil)
+	assert.Nil(t, p.Parse())
+
+	result, err := Export(p, Options{Binary: "/usr/bin/libcompose-cli"})
+	assert.Nil(t, err)
+	var web string
+	for _, unit := range result.Units {
+		if unit.Name == "myapp-web.service" {
+			web = string(unit.Content)
+		}
+	}
+
+	var flags string
+	for _, file := range []string{"--file testdata/docker-compose.yml", "--values testdata/values.yml", "--overlay testdata/overlay.yml"} {
+		parts := strings.SplitN(file, " ", 2)
+		abs, err := filepath.Abs(parts[1])
+		assert.Nil(t, err)
+		flags += " " + parts[0] + " " + abs
+	}
+	assert.Contains(t, web, "\nExecStart=/usr/bin/libcompose-cli --project-name myapp"+flags+" up web\n")
+	assert.Contains(t, web, "\nExecStop=/usr/bin/libcompose-cli --project-name myapp"+flags+" stop --timeout 60 web\n")
+
+	p = project.NewProject(&project.Context{
+		ComposeFiles:   []string{"testdata/docker-compose.yml"},
+		ComposeBytes:   [][]byte{content},
+		Values:         map[string]interface{}{"tag": "1.13"},
+		ProjectName:    "myapp",
+		ServiceFactory: testServiceFactory{},
+	}, nil, nil)
+	assert.Nil(t, p.Parse())
+	_, err = Export(p, Options{})
+	assert.NotNil(t, err)
+}
+
+func TestWrite(t *testing.T) {
+	result := &Result{Units: []Unit{
+		{Name: "a.service", Content: []byte("[Unit]\n")},
+		{Name: "a.target", Content: []byte("[Install]\n")},
+	}}
+
+	var buf bytes.Buffer
+	assert.Nil(t, result.Write(&buf))
+	assert.Equal(t, "# a.service\n[Unit]\n\n# a.target\n[Install]\n", buf.String())
+
+	dir, err := ioutil.TempDir("", "systemd")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, result.WriteDir(filepath.Join(dir, "units")))
+	content, err := ioutil.ReadFile(filepath.Join(dir, "units", "a.target"))
+	assert.Nil(t, err)
+	assert.Equal(t, "[Install]\n", string(content))
+}
+
+func TestCommandLine(t *testing.T) {
+	cases := map[string][]string{
+		`up web`:                      {"up", "web"},
+		`"/opt/my dir/cli" up`:        {"/opt/my dir/cli", "up"},
+		`--file "a\"b" ""`:            {"--file", `a"b`, ""},
+		`--project-name 100%%-$$HOME`: {"--project-name", "100%-$HOME"},
+	}
+	for expected, args := range cases {
+		assert.Equal(t, expected, commandLine(args), strings.Join(args, " "))
+	}
+}
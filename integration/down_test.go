@@ -34,3 +34,16 @@ func (s *CliSuite) TestDownMultiple(c *C) {
 	containers = s.GetContainersByProject(c, p)
 	c.Assert(len(containers), Equals, 0)
 }
+
+func (s *CliSuite) TestDownDryRun(c *C) {
+	p := s.ProjectFromText(c, "up", SimpleTemplate)
+
+	name := fmt.Sprintf("%s_%s_1", p, "hello")
+
+	_, output := s.FromTextCaptureOutput(c, p, "down", "--dry-run", SimpleTemplate)
+	c.Assert(output, Matches, fmt.Sprintf("(?s).*stop container %s \\(hello\\).*remove container %s \\(hello\\).*", name, name))
+
+	cn := s.GetContainerByName(c, name)
+	c.Assert(cn, NotNil)
+	c.Assert(cn.State.Running, Equals, true)
+}
@@ -1,9 +1,11 @@
 package integration
 
 import (
+	"bytes"
 	"fmt"
 	"os/exec"
 	"strings"
+	"syscall"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/go-connections/nat"
@@ -22,6 +24,109 @@ func (s *CliSuite) TestUp(c *C) {
 	c.Assert(cn.State.Running, Equals, true)
 }
 
+func (s *CliSuite) TestUpDryRun(c *C) {
+	p := s.RandomProject()
+	name := fmt.Sprintf("%s_%s_1", p, "hello")
+
+	_, output := s.FromTextCaptureOutput(c, p, "up", "--dry-run", SimpleTemplate)
+	c.Assert(output, Matches, fmt.Sprintf("(?s).*create container %s \\(hello\\): no container exists.*", name))
+	c.Assert(s.GetContainerByName(c, name), IsNil)
+
+	s.FromText(c, p, "up", SimpleTemplate)
+	_, output = s.FromTextCaptureOutput(c, p, "up", "--dry-run", SimpleTemplate)
+	c.Assert(output, Matches, fmt.Sprintf("(?s).*keep container %s \\(hello\\): up to date.*", name))
+}
+
+func (s *CliSuite) TestUpWait(c *C) {
+	p := s.RandomProject()
+	s.FromText(c, p, "up", "--wait", "--wait-timeout", "30", SimpleTemplate)
+
+	cn := s.GetContainerByName(c, fmt.Sprintf("%s_%s_1", p, "hello"))
+	c.Assert(cn, NotNil)
+	c.Assert(cn.State.Running, Equals, true)
+
+	_, output := s.FromTextCaptureOutput(c, p, "up", "--wait", "--wait-timeout", "30", `
+        hello:
+          image: busybox
+          command: sh -c "echo boom; exit 3"
+        `)
+	c.Assert(output, Matches, "(?s).*exited with code 3, last logs:.*boom.*")
+}
+
+func (s *CliSuite) TestUpExitCodeFrom(c *C) {
+	p := s.RandomProject()
+	// The helpers run up detached
+	cmd := exec.Command(s.command, "-p", p, "-f", "-", "up", "--exit-code-from", "worker")
+	cmd.Stdin = bytes.NewBufferString(`
+        worker:
+          image: busybox
+          command: sh -c "sleep 2; echo done; exit 3"
+        server:
+          image: busybox
+          command: top
+        `)
+	output, err := cmd.CombinedOutput()
+	exitErr, ok := err.(*exec.ExitError)
+	c.Assert(ok, Equals, true, Commentf("%v: %s", err, output))
+	c.Assert(exitErr.Sys().(syscall.WaitStatus).ExitStatus(), Equals, 3)
+	c.Assert(string(output), Matches, "(?s).*done.*")
+
+	cn := s.GetContainerByName(c, fmt.Sprintf("%s_%s_1", p, "server"))
+	c.Assert(cn, NotNil)
+	c.Assert(cn.State.Running, Equals, false)
+}
+
+func (s *CliSuite) TestUpRollingUpdate(c *C) {
+	p := s.RandomProject()
+	template := `
+version: '2'
+services:
+  hello:
+    image: busybox
+    command: top
+    environment: [VERSION=%s]
+    x-update-config:
+      parallelism: 2
+      order: start-first
+`
+	s.FromText(c, p, "up", fmt.Sprintf(template, "1"))
+	s.FromText(c, p, "scale", "hello=3", fmt.Sprintf(template, "1"))
+
+	_, output := s.FromTextCaptureOutput(c, p, "up", fmt.Sprintf(template, "2"))
+	c.Assert(output, Matches, "(?s).*Recreating hello: 1 to 2 of 3.*Recreating hello: 3 to 3 of 3.*")
+	for i := 1; i <= 3; i++ {
+		cn := s.GetContainerByName(c, fmt.Sprintf("%s_hello_%d", p, i))
+		c.Assert(cn, NotNil)
+		c.Assert(cn.State.Running, Equals, true)
+		c.Assert(utils.Contains(cn.Config.Env, "VERSION=2"), Equals, true)
+	}
+}
+
+func (s *CliSuite) TestUpRollback(c *C) {
+	p := s.RandomProject()
+	template := `
+version: '2'
+services:
+  hello:
+    image: busybox
+    command: top
+    environment: [VERSION=%s]
+    healthcheck:
+      test: %s
+      interval: 1s
+      retries: 1
+`
+	s.FromText(c, p, "up", fmt.Sprintf(template, "1", "true"))
+
+	_, output := s.FromTextCaptureOutput(c, p, "up", "--rollback", fmt.Sprintf(template, "2", "false"))
+	c.Assert(output, Matches, "(?s).*Rolled back .*is unhealthy.*")
+
+	cn := s.GetContainerByName(c, fmt.Sprintf("%s_hello_1", p))
+	c.Assert(cn, NotNil)
+	c.Assert(cn.State.Running, Equals, true)
+	c.Assert(utils.Contains(cn.Config.Env, "VERSION=1"), Equals, true)
+}
+
 func (s *CliSuite) TestUpNotExistService(c *C) {
 	p := s.ProjectFromText(c, "up", SimpleTemplate)
 
@@ -10,6 +10,9 @@ import (
 // Label represents a docker label.
 type Label string
 
+// Prefix is the prefix of the libcompose default labels.
+const Prefix = "com.docker.compose."
+
 // Libcompose default labels.
 const (
 	NUMBER  = Label("com.docker.compose.container-number")
@@ -17,6 +20,7 @@ const (
 	PROJECT = Label("com.docker.compose.project")
 	SERVICE = Label("com.docker.compose.service")
 	HASH    = Label("com.docker.compose.config-hash")
+	CONFIG  = Label("com.docker.compose.config")
 	VERSION = Label("com.docker.compose.version")
 )
 
@@ -0,0 +1,191 @@
+// Package lint checks the services of a compose project against a set of
+// rules, before anything is sent to the engine.
+package lint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/project"
+)
+
+// Severity is the severity of a finding.
+type Severity int
+
+// Definitions of the severities, from the least to the most severe.
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// Finding is a rule violation found on a service.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Service  string
+	// Key is the path of the offending value below the service, e.g.
+	// "privileged" or "ports.0". It is empty when the service as a whole is
+	// concerned (e.g. a missing key).
+	Key     string
+	Message string
+	// File, Line and Column locate the finding in the compose files, when
+	// it could be found.
+	File   string
+	Line   int
+	Column int
+}
+
+func (f Finding) String() string {
+	location := ""
+	if f.File != "" {
+		location = fmt.Sprintf("%s:%d:%d: ", f.File, f.Line, f.Column)
+	}
+	target := f.Service
+	if f.Key != "" {
+		target += "." + f.Key
+	}
+	return fmt.Sprintf("%s%s: %s: %s (%s)", location, f.Severity, target, f.Message, f.Rule)
+}
+
+// Rule checks the configuration of a service.
+type Rule interface {
+	// Name returns the name of the rule, used to enable or disable it.
+	Name() string
+	// Check returns the findings of the rule on the specified service. The
+	// Rule and Service fields of the findings are filled by the Linter.
+	Check(name string, service *config.ServiceConfig) []Finding
+}
+
+// Linter runs rules against the services of a project.
+type Linter struct {
+	Rules []Rule
+}
+
+// NewLinter creates a Linter with the specified rules, or with the built-in
+// rules if none is given.
+func NewLinter(rules ...Rule) *Linter {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+	return &Linter{
+		Rules: rules,
+	}
+}
+
+// Disable removes the rules with the specified names.
+func (l *Linter) Disable(names ...string) {
+	disabled := map[string]bool{}
+	for _, name := range names {
+		disabled[name] = true
+	}
+	rules := []Rule{}
+	for _, rule := range l.Rules {
+		if !disabled[rule.Name()] {
+			rules = append(rules, rule)
+		}
+	}
+	l.Rules = rules
+}
+
+// LintServices runs the rules against the specified services and returns
+// the findings ordered by service and rule.
+func (l *Linter) LintServices(services map[string]*config.ServiceConfig) []Finding {
+	names := []string{}
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	findings := []Finding{}
+	for _, name := range names {
+		for _, rule := range l.Rules {
+			for _, finding := range rule.Check(name, services[name]) {
+				finding.Rule = rule.Name()
+				finding.Service = name
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings
+}
+
+// Lint runs the rules against the services of the project and locates the
+// findings in the project compose files.
+func (l *Linter) Lint(p *project.Project) []Finding {
+	findings := l.LintServices(p.ServiceConfigs.All())
+	Locate(findings, p.Files)
+	return findings
+}
+
+// Locate fills the location of the findings from the specified compose
+// files. Later files override earlier ones, so they are looked up first.
+// Files that can't be read or parsed are ignored.
+func Locate(findings []Finding, files []string) {
+	documents := []*config.Document{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			documents = append(documents, nil)
+			continue
+		}
+		document, err := config.ParseDocument(content)
+		if err != nil {
+			documents = append(documents, nil)
+			continue
+		}
+		documents = append(documents, document)
+	}
+
+	for i := range findings {
+		locate(&findings[i], files, documents)
+	}
+}
+
+func locate(finding *Finding, files []string, documents []*config.Document) {
+	paths := []config.Path{}
+	if finding.Key != "" {
+		key := config.ParsePath(finding.Key)
+		paths = append(paths, append(config.Path{"services", finding.Service}, key...), append(config.Path{finding.Service}, key...))
+	}
+	paths = append(paths, config.Path{"services", finding.Service}, config.Path{finding.Service})
+
+	for _, path := range paths {
+		for i := len(documents) - 1; i >= 0; i-- {
+			if documents[i] == nil {
+				continue
+			}
+			if line, column, ok := documents[i].Position(path); ok {
+				finding.File = files[i]
+				finding.Line = line
+				finding.Column = column
+				return
+			}
+		}
+	}
+}
+
+// HasErrors returns whether one of the findings is at least as severe as
+// the specified severity.
+func HasErrors(findings []Finding, severity Severity) bool {
+	for _, finding := range findings {
+		if finding.Severity >= severity {
+			return true
+		}
+	}
+	return false
+}
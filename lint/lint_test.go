@@ -0,0 +1,57 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/docker/libcompose/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules(t *testing.T) {
+	cases := []struct {
+		rule     Rule
+		service  config.ServiceConfig
+		expected int
+	}{
+		{&NoPrivileged{}, config.ServiceConfig{Privileged: true}, 1},
+		{&NoPrivileged{}, config.ServiceConfig{}, 0},
+		{&NoLatestImage{}, config.ServiceConfig{Image: "nginx"}, 1},
+		{&NoLatestImage{}, config.ServiceConfig{Image: "nginx:latest"}, 1},
+		{&NoLatestImage{}, config.ServiceConfig{Image: "nginx:1.11"}, 0},
+		{&NoLatestImage{}, config.ServiceConfig{Image: "nginx@sha256:0ed5d5928d4737458944eb604cc8509e245c3e19d02ad83935398bc4b991aac7"}, 0},
+		{&NoLatestImage{}, config.ServiceConfig{}, 0},
+		{&MemoryLimitRequired{}, config.ServiceConfig{}, 1},
+		{&MemoryLimitRequired{}, config.ServiceConfig{MemLimit: 1024}, 0},
+		{&NoHostNetwork{}, config.ServiceConfig{NetworkMode: "host"}, 1},
+		{&NoHostNetwork{}, config.ServiceConfig{NetworkMode: "bridge"}, 0},
+	}
+	for _, c := range cases {
+		findings := c.rule.Check("test", &c.service)
+		assert.Len(t, findings, c.expected, "%s on %#v", c.rule.Name(), c.service)
+	}
+}
+
+func TestLintAndLocate(t *testing.T) {
+	linter := NewLinter()
+	linter.Disable("no-latest-image")
+
+	findings := linter.LintServices(map[string]*config.ServiceConfig{
+		"web": {Image: "nginx", Privileged: true, MemLimit: 64 * 1024 * 1024},
+		"db":  {Image: "postgres:9.6", NetworkMode: "host"},
+	})
+	Locate(findings, []string{"testdata/docker-compose.yml"})
+
+	strings := []string{}
+	for _, finding := range findings {
+		strings = append(strings, finding.String())
+	}
+	assert.Equal(t, []string{
+		"testdata/docker-compose.yml:7:3: warning: db: mem_limit should be set (memory-limit-required)",
+		"testdata/docker-compose.yml:9:5: error: db.network_mode: containers must not use the host network (no-host-network)",
+		"testdata/docker-compose.yml:5:5: error: web.privileged: containers must not run privileged (no-privileged)",
+	}, strings)
+
+	assert.True(t, HasErrors(findings, Error))
+	assert.False(t, HasErrors(findings[:1], Error))
+	assert.True(t, HasErrors(findings[:1], Warning))
+}
@@ -0,0 +1,112 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/libcompose/config"
+)
+
+// DefaultRules returns the built-in rules.
+func DefaultRules() []Rule {
+	return []Rule{
+		&NoPrivileged{},
+		&NoLatestImage{},
+		&MemoryLimitRequired{},
+		&NoHostNetwork{},
+	}
+}
+
+// NoPrivileged reports services running privileged containers.
+type NoPrivileged struct{}
+
+// Name implements Rule.Name.
+func (r *NoPrivileged) Name() string {
+	return "no-privileged"
+}
+
+// Check implements Rule.Check.
+func (r *NoPrivileged) Check(name string, service *config.ServiceConfig) []Finding {
+	if !service.Privileged {
+		return nil
+	}
+	return []Finding{{
+		Severity: Error,
+		Key:      "privileged",
+		Message:  "containers must not run privileged",
+	}}
+}
+
+// NoLatestImage reports services using the latest tag of an image, explicitly
+// or by not specifying any tag nor digest.
+type NoLatestImage struct{}
+
+// Name implements Rule.Name.
+func (r *NoLatestImage) Name() string {
+	return "no-latest-image"
+}
+
+// Check implements Rule.Check.
+func (r *NoLatestImage) Check(name string, service *config.ServiceConfig) []Finding {
+	if service.Image == "" {
+		return nil
+	}
+	named, err := reference.ParseNormalizedNamed(service.Image)
+	if err != nil {
+		return []Finding{{
+			Severity: Error,
+			Key:      "image",
+			Message:  fmt.Sprintf("invalid image reference %s: %v", service.Image, err),
+		}}
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return nil
+	}
+	if tagged, ok := named.(reference.Tagged); ok && tagged.Tag() != "latest" {
+		return nil
+	}
+	return []Finding{{
+		Severity: Error,
+		Key:      "image",
+		Message:  fmt.Sprintf("image %s must be pinned to a tag other than latest or to a digest", service.Image),
+	}}
+}
+
+// MemoryLimitRequired reports services without a memory limit.
+type MemoryLimitRequired struct{}
+
+// Name implements Rule.Name.
+func (r *MemoryLimitRequired) Name() string {
+	return "memory-limit-required"
+}
+
+// Check implements Rule.Check.
+func (r *MemoryLimitRequired) Check(name string, service *config.ServiceConfig) []Finding {
+	if service.MemLimit > 0 {
+		return nil
+	}
+	return []Finding{{
+		Severity: Warning,
+		Message:  "mem_limit should be set",
+	}}
+}
+
+// NoHostNetwork reports services sharing the network namespace of the host.
+type NoHostNetwork struct{}
+
+// Name implements Rule.Name.
+func (r *NoHostNetwork) Name() string {
+	return "no-host-network"
+}
+
+// Check implements Rule.Check.
+func (r *NoHostNetwork) Check(name string, service *config.ServiceConfig) []Finding {
+	if service.NetworkMode != "host" {
+		return nil
+	}
+	return []Finding{{
+		Severity: Error,
+		Key:      "network_mode",
+		Message:  "containers must not use the host network",
+	}}
+}
@@ -31,6 +31,21 @@ type Context struct {
 	LoggerFactory       logger.Factory
 	IgnoreMissingConfig bool
 	Project             *Project
+	// When ValuesFiles or Values are set, the compose files are rendered as
+	// templates. Top-level keys of the values files override Values.
+	ValuesFiles []string
+	Values      map[string]interface{}
+	// OverlayFiles are patches applied in order once the compose files
+	// are merged, see config.Overlay.
+	OverlayFiles []string
+	OverlayBytes [][]byte
+	// ShowSecrets disables the masking of sensitive values in the output of
+	// Config and in the events logged, see config.Sensitive.
+	ShowSecrets bool
+	// Parallelism is the number of services and containers acted on at
+	// once, unlimited if not positive. The services still wait for those
+	// they depend on.
+	Parallelism int
 }
 
 func (c *Context) readComposeFiles() error {
@@ -67,6 +82,64 @@ func (c *Context) readComposeFiles() error {
 	return nil
 }
 
+func (c *Context) readOverlayFiles() error {
+	if c.OverlayBytes != nil {
+		return nil
+	}
+
+	for _, overlayFile := range c.OverlayFiles {
+		overlayBytes, err := ioutil.ReadFile(overlayFile)
+		if err != nil {
+			logrus.Errorf("Failed to open the overlay file: %s", overlayFile)
+			return err
+		}
+		c.OverlayBytes = append(c.OverlayBytes, overlayBytes)
+	}
+
+	return nil
+}
+
+func (c *Context) renderComposeFiles() error {
+	if c.Values == nil && len(c.ValuesFiles) == 0 {
+		return nil
+	}
+
+	var contents [][]byte
+	for _, valuesFile := range c.ValuesFiles {
+		content, err := ioutil.ReadFile(valuesFile)
+		if err != nil {
+			logrus.Errorf("Failed to open the values file: %s", valuesFile)
+			return err
+		}
+		contents = append(contents, content)
+	}
+	values, err := config.ParseValues(contents...)
+	if err != nil {
+		return err
+	}
+	merged := map[string]interface{}{}
+	for key, value := range c.Values {
+		merged[key] = value
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+
+	for i, composeBytes := range c.ComposeBytes {
+		file := "-"
+		if i < len(c.ComposeFiles) {
+			file = c.ComposeFiles[i]
+		}
+		rendered, err := config.RenderTemplate(file, composeBytes, merged)
+		if err != nil {
+			return err
+		}
+		c.ComposeBytes[i] = rendered
+	}
+
+	return nil
+}
+
 func (c *Context) determineProject() error {
 	name, err := c.lookupProjectName()
 	if err != nil {
@@ -132,6 +205,14 @@ func (c *Context) open() error {
 		return err
 	}
 
+	if err := c.renderComposeFiles(); err != nil {
+		return err
+	}
+
+	if err := c.readOverlayFiles(); err != nil {
+		return err
+	}
+
 	if err := c.determineProject(); err != nil {
 		return err
 	}
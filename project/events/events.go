@@ -99,6 +99,10 @@ const (
 	ProjectUnpauseDone   = EventType(iota)
 	ProjectStopStart     = EventType(iota)
 	ProjectStopDone      = EventType(iota)
+
+	// Events added since are appended, to keep the values of the others
+	ContainerRollbackStart = EventType(iota)
+	ContainerRollback      = EventType(iota)
 )
 
 func (e EventType) String() string {
@@ -108,6 +112,10 @@ func (e EventType) String() string {
 		m = "Created container"
 	case ContainerStarted:
 		m = "Started container"
+	case ContainerRollbackStart:
+		m = "Rolling back container"
+	case ContainerRollback:
+		m = "Rolled back container"
 
 	case ServiceAdd:
 		m = "Adding"
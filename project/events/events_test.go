@@ -19,3 +19,13 @@ func TestEventEquality(t *testing.T) {
 		t.Fatal("Events match")
 	}
 }
+
+func TestEventValues(t *testing.T) {
+	// The values of the events are part of the API and must not change
+	if ServiceAdd != 3 || ProjectStopDone != 56 {
+		t.Fatalf("Event values changed: ServiceAdd is %d, ProjectStopDone is %d", ServiceAdd, ProjectStopDone)
+	}
+	if ContainerRollbackStart != ProjectStopDone+1 || ContainerRollback != ProjectStopDone+2 {
+		t.Fatalf("Rollback events are not appended: %d %d", ContainerRollbackStart, ContainerRollback)
+	}
+}
@@ -29,6 +29,9 @@ var (
 		events.ServicePause:        true,
 		events.ServiceUnpauseStart: true,
 		events.ServiceUnpause:      true,
+
+		events.ContainerRollbackStart: true,
+		events.ContainerRollback:      true,
 	}
 )
 
@@ -1,5 +1,7 @@
 package options
 
+import "time"
+
 // Build holds options of compose build.
 type Build struct {
 	NoCache     bool
@@ -37,6 +39,24 @@ type Run struct {
 // Up holds options of compose up.
 type Up struct {
 	Create
+	// Wait makes up return once the containers are running and healthy.
+	Wait bool
+	// WaitTimeout is how long up waits for them, unlimited if zero.
+	WaitTimeout time.Duration
+	// Rollback keeps the previous container of a recreated one until the
+	// new one is started and healthy, and puts it back if the new one fails.
+	Rollback bool
+}
+
+// Attach holds options of following the output of the containers of a
+// project.
+type Attach struct {
+	// AbortOnContainerExit stops the services as soon as one of their
+	// containers exits.
+	AbortOnContainerExit bool
+	// ExitCodeFrom is the service whose container exit code is returned, it
+	// implies AbortOnContainerExit.
+	ExitCodeFrom string
 }
 
 // ImageType defines the type of image (local, all)
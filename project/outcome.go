@@ -0,0 +1,143 @@
+package project
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/libcompose/utils"
+)
+
+// Outcome is the result of an operation for a service.
+type Outcome string
+
+// Definitions of the outcomes.
+const (
+	OutcomeDone     = Outcome("done")
+	OutcomeSkipped  = Outcome("skipped")
+	OutcomeFailed   = Outcome("failed")
+	OutcomeCanceled = Outcome("canceled")
+)
+
+// DependencyError is the error of a service the operation skipped because
+// a service it depends on failed or was skipped.
+type DependencyError struct {
+	Dependency string
+	Err        error
+}
+
+func (e *DependencyError) Error() string {
+	return fmt.Sprintf("Dependency %s did not complete", e.Dependency)
+}
+
+// CanceledError is returned by the operations whose context is canceled,
+// with what they had completed. The services not acted on yet are left as
+// they were, those being acted on stop at their current engine call.
+type CanceledError struct {
+	// Err is the error of the context, context.Canceled or
+	// context.DeadlineExceeded.
+	Err error
+	// Completed lists the services the operation was done for.
+	Completed []string
+	// Errors holds the errors of the services that failed before the
+	// cancellation.
+	Errors utils.MultiError
+}
+
+func (e *CanceledError) Error() string {
+	message := "Operation canceled: " + e.Err.Error()
+	if len(e.Completed) > 0 {
+		message += fmt.Sprintf(" (completed: %s)", strings.Join(e.Completed, ", "))
+	} else {
+		message += " (nothing completed)"
+	}
+	if len(e.Errors) > 0 {
+		message += "\n" + e.Errors.Error()
+	}
+	return message
+}
+
+// Cause returns the error of the context.
+func (e *CanceledError) Cause() error {
+	return e.Err
+}
+
+// Unwrap returns the error of the context.
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// isCanceled checks if err comes from the cancellation of ctx, like the
+// errors of the engine calls it aborted.
+func isCanceled(ctx context.Context, err error) bool {
+	ctxErr := ctx.Err()
+	if ctxErr == nil {
+		return false
+	}
+	for err != nil {
+		if err == ctxErr {
+			return true
+		}
+		switch e := err.(type) {
+		case *url.Error:
+			err = e.Err
+		case utils.MultiError:
+			for _, containerErr := range e {
+				if !isCanceled(ctx, containerErr) {
+					return false
+				}
+			}
+			return true
+		case interface {
+			Cause() error
+		}:
+			if e.Cause() == err {
+				return false
+			}
+			err = e.Cause()
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// ServiceOutcome is the outcome of an operation for a service, with the
+// error of the service if it failed or was skipped.
+type ServiceOutcome struct {
+	Service string
+	Outcome Outcome
+	Err     error
+}
+
+func (o ServiceOutcome) String() string {
+	if o.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", o.Service, o.Outcome, o.Err)
+	}
+	return fmt.Sprintf("%s: %s", o.Service, o.Outcome)
+}
+
+// Summary holds the outcomes of an operation, sorted by service name.
+type Summary []ServiceOutcome
+
+// Summary returns the outcomes of the last operation run on the services,
+// for those it selected. Its errors are returned by the operation as a
+// utils.MultiError mapping the names of the failed services to their
+// errors, which map the names of the failed containers to theirs.
+func (p *Project) Summary() Summary {
+	p.summaryMu.Lock()
+	defer p.summaryMu.Unlock()
+	return append(Summary{}, p.summary...)
+}
+
+func (p *Project) setSummary(summary Summary) {
+	sort.Slice(summary, func(i, j int) bool {
+		return summary[i].Service < summary[j].Service
+	})
+	p.summaryMu.Lock()
+	defer p.summaryMu.Unlock()
+	p.summary = summary
+}
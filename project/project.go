@@ -7,6 +7,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"golang.org/x/net/context"
 
@@ -34,7 +35,11 @@ type Project struct {
 	Files          []string
 	ReloadCallback func() error
 	ParseOptions   *config.ParseOptions
+	// Sensitive records the values masked when printed, unless the context
+	// shows secrets.
+	Sensitive *config.Sensitive
 
+	defaults      *config.ProjectDefaults
 	runtime       RuntimeProject
 	networks      Networks
 	volumes       Volumes
@@ -44,6 +49,10 @@ type Project struct {
 	upCount       int
 	listeners     []chan<- events.Event
 	hasListeners  bool
+	slotsOnce     sync.Once
+	slots         utils.Semaphore
+	summaryMu     sync.Mutex
+	summary       Summary
 }
 
 // NewProject creates a new project with the specified context.
@@ -55,6 +64,8 @@ func NewProject(context *Context, runtime RuntimeProject, parseOptions *config.P
 		ServiceConfigs: config.NewServiceConfigs(),
 		VolumeConfigs:  make(map[string]*config.VolumeConfig),
 		NetworkConfigs: make(map[string]*config.NetworkConfig),
+		Sensitive:      config.NewSensitive(),
+		defaults:       config.NewProjectDefaults(),
 	}
 
 	if context.LoggerFactory == nil {
@@ -98,6 +109,16 @@ func NewProject(context *Context, runtime RuntimeProject, parseOptions *config.P
 	return p
 }
 
+// Slots returns the semaphore limiting the operations run at once to the
+// Parallelism of the context, shared by the services and their containers.
+// It is created on first use, so that Parallelism can be set until then.
+func (p *Project) Slots() utils.Semaphore {
+	p.slotsOnce.Do(func() {
+		p.slots = utils.NewSemaphore(p.context.Parallelism)
+	})
+	return p.slots
+}
+
 // Parse populates project information based on its context. It sets up the name,
 // the composefile and the composebytes (the composefile content).
 func (p *Project) Parse() error {
@@ -120,13 +141,17 @@ func (p *Project) Parse() error {
 			if i < len(p.context.ComposeFiles) {
 				file = p.Files[i]
 			}
-			if err := p.load(file, composeBytes); err != nil {
+			if err := p.merge(file, composeBytes); err != nil {
 				return err
 			}
 		}
 	}
 
-	return nil
+	if err := p.applyOverlays(); err != nil {
+		return err
+	}
+
+	return p.finishLoad()
 }
 
 // CreateService creates a service with the specified name based. If there
@@ -212,7 +237,23 @@ func (p *Project) Load(bytes []byte) error {
 }
 
 func (p *Project) load(file string, bytes []byte) error {
-	version, serviceConfigs, volumeConfigs, networkConfigs, err := config.Merge(p.ServiceConfigs, p.context.EnvironmentLookup, p.context.ResourceLookup, file, bytes, p.ParseOptions)
+	if err := p.merge(file, bytes); err != nil {
+		return err
+	}
+	return p.finishLoad()
+}
+
+// merge merges a compose file into the service, volume and network configs
+// of the project.
+func (p *Project) merge(file string, bytes []byte) error {
+	options := config.DefaultParseOptions()
+	if p.ParseOptions != nil {
+		options = *p.ParseOptions
+	}
+	options.Sensitive = p.Sensitive
+	options.ProjectDefaults = p.defaults
+
+	version, serviceConfigs, volumeConfigs, networkConfigs, err := config.Merge(p.ServiceConfigs, p.context.EnvironmentLookup, p.context.ResourceLookup, file, bytes, &options)
 	if err != nil {
 		log.Errorf("Could not parse config for project %s : %v", p.Name, err)
 		return err
@@ -241,9 +282,77 @@ func (p *Project) load(file string, bytes []byte) error {
 		}
 	}
 
+	return nil
+}
+
+// applyOverlays patches the merged configs with the overlays of the context.
+func (p *Project) applyOverlays() error {
+	if len(p.context.OverlayBytes) == 0 {
+		return nil
+	}
+
+	var overlays []*config.Overlay
+	for i, overlayBytes := range p.context.OverlayBytes {
+		file := ""
+		if i < len(p.context.OverlayFiles) {
+			file = p.context.OverlayFiles[i]
+		}
+		overlay, err := config.ParseOverlay(file, overlayBytes)
+		if err != nil {
+			return err
+		}
+		overlays = append(overlays, overlay)
+	}
+
+	serviceConfigs, volumeConfigs, networkConfigs, err := config.ApplyOverlays(p.ServiceConfigs.All(), p.VolumeConfigs, p.NetworkConfigs, overlays, p.ParseOptions)
+	if err != nil {
+		log.Errorf("Could not apply overlays for project %s : %v", p.Name, err)
+		return err
+	}
+
+	for _, name := range p.ServiceConfigs.Keys() {
+		if _, ok := serviceConfigs[name]; !ok {
+			p.ServiceConfigs.Remove(name)
+		}
+	}
+	for name, config := range serviceConfigs {
+		if p.ServiceConfigs.Has(name) {
+			p.ServiceConfigs.Add(name, config)
+		} else if err := p.AddConfig(name, config); err != nil {
+			return err
+		}
+	}
+
+	existingVolumes := p.VolumeConfigs
+	p.VolumeConfigs = map[string]*config.VolumeConfig{}
+	for name, config := range volumeConfigs {
+		if _, ok := existingVolumes[name]; ok {
+			p.VolumeConfigs[name] = config
+		} else if err := p.AddVolumeConfig(name, config); err != nil {
+			return err
+		}
+	}
+
+	existingNetworks := p.NetworkConfigs
+	p.NetworkConfigs = map[string]*config.NetworkConfig{}
+	for name, config := range networkConfigs {
+		if _, ok := existingNetworks[name]; ok {
+			p.NetworkConfigs[name] = config
+		} else if err := p.AddNetworkConfig(name, config); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// finishLoad resolves the names of the networks and volumes of the services
+// and creates them, once every compose file is merged.
+func (p *Project) finishLoad() error {
 	// Update network configuration a little bit
 	p.handleNetworkConfig()
 	p.handleVolumeConfig()
+	p.Sensitive.Collect(p.ServiceConfigs.All())
 
 	if p.context.NetworksFactory != nil {
 		networks, err := p.context.NetworksFactory.Create(p.Name, p.NetworkConfigs, p.ServiceConfigs, p.isNetworkEnabled())
@@ -378,10 +487,10 @@ func (p *Project) loadWrappers(wrappers map[string]*serviceWrapper, servicesToCo
 	return nil
 }
 
-func (p *Project) perform(start, done events.EventType, services []string, action wrapperAction, cycleAction serviceAction) error {
+func (p *Project) perform(ctx context.Context, start, done events.EventType, services []string, action wrapperAction, cycleAction serviceAction) error {
 	p.Notify(start, "", nil)
 
-	err := p.forEach(services, action, cycleAction)
+	err := p.forEach(ctx, services, action, cycleAction)
 
 	p.Notify(done, "", nil)
 	return err
@@ -391,7 +500,7 @@ func isSelected(wrapper *serviceWrapper, selected map[string]bool) bool {
 	return len(selected) == 0 || selected[wrapper.name]
 }
 
-func (p *Project) forEach(services []string, action wrapperAction, cycleAction serviceAction) error {
+func (p *Project) forEach(ctx context.Context, services []string, action wrapperAction, cycleAction serviceAction) error {
 	selected := make(map[string]bool)
 	wrappers := make(map[string]*serviceWrapper)
 
@@ -399,7 +508,7 @@ func (p *Project) forEach(services []string, action wrapperAction, cycleAction s
 		selected[s] = true
 	}
 
-	return p.traverse(true, selected, wrappers, action, cycleAction)
+	return p.traverse(ctx, true, selected, wrappers, action, cycleAction)
 }
 
 func (p *Project) startService(wrappers map[string]*serviceWrapper, history []string, selected, launched map[string]bool, wrapper *serviceWrapper, action wrapperAction, cycleAction serviceAction) error {
@@ -453,7 +562,7 @@ func (p *Project) startService(wrappers map[string]*serviceWrapper, history []st
 	return nil
 }
 
-func (p *Project) traverse(start bool, selected map[string]bool, wrappers map[string]*serviceWrapper, action wrapperAction, cycleAction serviceAction) error {
+func (p *Project) traverse(ctx context.Context, start bool, selected map[string]bool, wrappers map[string]*serviceWrapper, action wrapperAction, cycleAction serviceAction) error {
 	restart := false
 	wrapperList := []string{}
 
@@ -488,20 +597,44 @@ func (p *Project) traverse(start bool, selected map[string]bool, wrappers map[st
 		}
 	}
 
-	var firstError error
+	errs := utils.MultiError{}
+	var summary Summary
 
 	for _, wrapper := range wrappers {
 		if !isSelected(wrapper, selected) {
 			continue
 		}
-		if err := wrapper.Wait(); err == ErrRestart {
+		err := wrapper.Wait()
+		if err == ErrRestart {
 			restart = true
+			continue
+		}
+		outcome := OutcomeDone
+		if _, ok := err.(*DependencyError); ok {
+			log.Warnf("Skipped %s: %v", wrapper.name, err)
+			outcome = OutcomeSkipped
+		} else if isCanceled(ctx, err) {
+			outcome = OutcomeCanceled
 		} else if err != nil {
 			log.Errorf("Failed to start: %s : %v", wrapper.name, err)
-			if firstError == nil {
-				firstError = err
+			errs[wrapper.name] = err
+			outcome = OutcomeFailed
+		}
+		summary = append(summary, ServiceOutcome{Service: wrapper.name, Outcome: outcome, Err: err})
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		p.setSummary(summary)
+		canceled := &CanceledError{Err: ctxErr}
+		for _, outcome := range p.Summary() {
+			if outcome.Outcome == OutcomeDone {
+				canceled.Completed = append(canceled.Completed, outcome.Service)
 			}
 		}
+		if len(errs) > 0 {
+			canceled.Errors = errs
+		}
+		return canceled
 	}
 
 	if restart {
@@ -510,9 +643,13 @@ func (p *Project) traverse(start bool, selected map[string]bool, wrappers map[st
 				log.Errorf("Failed calling callback: %v", err)
 			}
 		}
-		return p.traverse(false, selected, wrappers, action, cycleAction)
+		return p.traverse(ctx, false, selected, wrappers, action, cycleAction)
 	}
-	return firstError
+	p.setSummary(summary)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
 }
 
 // AddListener adds the specified listener to the project.
@@ -530,12 +667,21 @@ func (p *Project) AddListener(c chan<- events.Event) {
 }
 
 // Notify notifies all project listener with the specified eventType, service name and datas.
-// This implements implicitly events.Notifier interface.
+// This implements implicitly events.Notifier interface. The sensitive values of
+// the data are masked unless the context shows secrets.
 func (p *Project) Notify(eventType events.EventType, serviceName string, data map[string]string) {
 	if eventType == events.NoEvent {
 		return
 	}
 
+	if len(data) > 0 && !p.context.ShowSecrets {
+		redacted := make(map[string]string, len(data))
+		for key, value := range data {
+			redacted[key] = p.Sensitive.Redact(value)
+		}
+		data = redacted
+	}
+
 	event := events.Event{
 		EventType:   eventType,
 		ServiceName: serviceName,
@@ -555,5 +701,5 @@ func (p *Project) GetServiceConfig(name string) (*config.ServiceConfig, bool) {
 
 // IsNamedVolume returns whether the specified volume (string) is a named volume or not.
 func IsNamedVolume(volume string) bool {
-	return !strings.HasPrefix(volume, ".") && !strings.HasPrefix(volume, "/") && !strings.HasPrefix(volume, "~")
+	return config.IsNamedVolume(volume)
 }
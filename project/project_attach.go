@@ -0,0 +1,191 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/libcompose/project/options"
+	"github.com/docker/libcompose/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// ContainerLogger is implemented by the services that can follow the output
+// of a single container, like one created after they were attached to.
+type ContainerLogger interface {
+	LogContainer(ctx context.Context, id string, follow bool) error
+}
+
+// ExitCoder is implemented by the containers that can tell the code they
+// exited with.
+type ExitCoder interface {
+	ExitCode(ctx context.Context) (int, error)
+}
+
+// exitCodeGracePeriod is how long Attach waits for a container exit event
+// that may still be on its way.
+var exitCodeGracePeriod = 10 * time.Second
+
+// Attach follows the output of the containers of the specified services, or
+// of all of them if none is specified, including the containers started once
+// attached, like recreated ones. It returns once the followed containers have
+// all stopped or the context is canceled. With AbortOnContainerExit, it stops
+// the services as soon as one of their containers exits and returns the exit
+// code of that container, or of the container of ExitCodeFrom if set. The
+// services must implement ContainerLogger.
+func (p *Project) Attach(ctx context.Context, opts options.Attach, services ...string) (int, error) {
+	abort := opts.AbortOnContainerExit || opts.ExitCodeFrom != ""
+	if opts.ExitCodeFrom != "" {
+		if !p.ServiceConfigs.Has(opts.ExitCodeFrom) {
+			return 0, errors.New("No such service: " + opts.ExitCodeFrom)
+		}
+		if len(services) > 0 && !utils.Contains(services, opts.ExitCodeFrom) {
+			return 0, fmt.Errorf("Service %s isn't among the attached services", opts.ExitCodeFrom)
+		}
+	}
+	if len(services) == 0 {
+		services = p.ServiceConfigs.Keys()
+	}
+
+	attachCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Listen before listing the containers so that none is missed
+	evts, err := p.Events(attachCtx, services...)
+	if err != nil {
+		return 0, err
+	}
+
+	loggers := map[string]ContainerLogger{}
+	attached := map[string]bool{}
+	ended := make(chan error)
+	attach := func(service, id string) bool {
+		logger, ok := loggers[service]
+		if !ok || attached[id] {
+			return false
+		}
+		attached[id] = true
+		go func() {
+			err := logger.LogContainer(attachCtx, id, true)
+			select {
+			case ended <- err:
+			case <-attachCtx.Done():
+			}
+		}()
+		return true
+	}
+
+	type exit struct {
+		service string
+		code    int
+	}
+	// With AbortOnContainerExit, the containers that exited before being
+	// attached to, like during up, count as exiting
+	var exited []exit
+	running := 0
+	for _, name := range services {
+		service, err := p.CreateService(name)
+		if err != nil {
+			return 0, err
+		}
+		logger, ok := service.(ContainerLogger)
+		if !ok {
+			return 0, fmt.Errorf("Service %s can't be attached to", name)
+		}
+		loggers[name] = logger
+		containers, err := service.Containers(attachCtx)
+		if err != nil {
+			return 0, err
+		}
+		for _, c := range containers {
+			if attach(name, c.ID()) {
+				running++
+			}
+			if abort && !c.IsRunning(attachCtx) {
+				code := 0
+				if exitCoder, ok := c.(ExitCoder); ok {
+					if code, err = exitCoder.ExitCode(attachCtx); err != nil {
+						return 0, err
+					}
+				}
+				exited = append(exited, exit{name, code})
+			}
+		}
+	}
+	if running == 0 && len(exited) == 0 {
+		return 0, nil
+	}
+
+	exitCode := 0
+	exitCodes := map[string]int{}
+	aborting, stopped := false, false
+	stopDone := make(chan error, 1)
+	var deadline <-chan time.Time
+	containerExited := func(service string, code int) {
+		exitCodes[service] = code
+		if !abort || aborting {
+			return
+		}
+		log.Infof("Aborting on container exit...")
+		aborting, deadline = true, nil
+		if opts.ExitCodeFrom == "" {
+			exitCode = code
+		}
+		go func() {
+			stopDone <- p.Stop(ctx, 0, services...)
+		}()
+	}
+	for _, e := range exited {
+		containerExited(e.service, e.code)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return exitCode, ctx.Err()
+		case err := <-ended:
+			running--
+			if err != nil && attachCtx.Err() == nil {
+				log.Warnf("Failed to follow the output of a container: %v", err)
+			}
+			if running == 0 && !abort {
+				return exitCode, nil
+			}
+			if running == 0 && !aborting {
+				// The exit event of the last container may still be on its way
+				deadline = time.After(exitCodeGracePeriod)
+			}
+		case event := <-evts:
+			switch event.Event {
+			case "start":
+				if attach(event.Service, event.ID) {
+					running++
+					if !aborting {
+						deadline = nil
+					}
+				}
+			case "die":
+				code, _ := strconv.Atoi(event.Attributes["exitCode"])
+				containerExited(event.Service, code)
+			}
+		case err := <-stopDone:
+			if err != nil || opts.ExitCodeFrom == "" {
+				return exitCode, err
+			}
+			stopped = true
+			deadline = time.After(exitCodeGracePeriod)
+		case <-deadline:
+			if !aborting {
+				return exitCode, nil
+			}
+			return 0, fmt.Errorf("No exit code received from service %s", opts.ExitCodeFrom)
+		}
+		if stopped {
+			if code, ok := exitCodes[opts.ExitCodeFrom]; ok {
+				return code, nil
+			}
+		}
+	}
+}
@@ -13,11 +13,16 @@ type ExportedConfig struct {
 	Networks map[string]*config.NetworkConfig `yaml:"networks"`
 }
 
-// Config validates and print the compose file.
+// Config validates and print the compose file. The sensitive values are
+// masked unless the context shows secrets.
 func (p *Project) Config() (string, error) {
+	services := p.ServiceConfigs.All()
+	if !p.context.ShowSecrets {
+		services = p.Sensitive.RedactServices(services)
+	}
 	cfg := ExportedConfig{
 		Version:  "2.0",
-		Services: p.ServiceConfigs.All(),
+		Services: services,
 		Volumes:  p.VolumeConfigs,
 		Networks: p.NetworkConfigs,
 	}
@@ -25,3 +30,92 @@ func (p *Project) Config() (string, error) {
 	bytes, err := yaml.Marshal(cfg)
 	return string(bytes), err
 }
+
+// Redact masks the sensitive values found in text, unless the context shows
+// secrets.
+func (p *Project) Redact(text string) string {
+	if p.context.ShowSecrets {
+		return text
+	}
+	return p.Sensitive.Redact(text)
+}
+
+// LoadBuilder validates the configurations of a builder and adds them to the
+// project, as if they were loaded from a version 2 compose file.
+func (p *Project) LoadBuilder(builder *config.Builder) error {
+	serviceConfigs, volumeConfigs, networkConfigs, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	p.configVersion = "2"
+
+	for name, config := range volumeConfigs {
+		if err := p.AddVolumeConfig(name, config); err != nil {
+			return err
+		}
+	}
+
+	for name, config := range networkConfigs {
+		if err := p.AddNetworkConfig(name, config); err != nil {
+			return err
+		}
+	}
+
+	for name, config := range serviceConfigs {
+		if err := p.AddConfig(name, config); err != nil {
+			return err
+		}
+	}
+
+	return p.finishLoad()
+}
+
+// Rendered returns the compose files as they are parsed, after they are
+// rendered with the values of the context.
+func (p *Project) Rendered() [][]byte {
+	return p.context.ComposeBytes
+}
+
+// ValuesFiles returns the values files the compose files are rendered with.
+func (p *Project) ValuesFiles() []string {
+	return p.context.ValuesFiles
+}
+
+// Values returns the values the compose files are rendered with besides
+// those of the values files.
+func (p *Project) Values() map[string]interface{} {
+	return p.context.Values
+}
+
+// OverlayFiles returns the overlay files applied to the project.
+func (p *Project) OverlayFiles() []string {
+	return p.context.OverlayFiles
+}
+
+// Provenance merges the compose files of the project again, recording the
+// origin of the values of its services. Overlays are not taken into account.
+func (p *Project) Provenance() (*config.Provenance, error) {
+	options := config.DefaultParseOptions()
+	if p.ParseOptions != nil {
+		options = *p.ParseOptions
+	}
+	options.Provenance = config.NewProvenance()
+	options.ProjectDefaults = config.NewProjectDefaults()
+
+	serviceConfigs := config.NewServiceConfigs()
+	for i, composeBytes := range p.context.ComposeBytes {
+		file := ""
+		if i < len(p.Files) {
+			file = p.Files[i]
+		}
+		_, configs, _, _, err := config.Merge(serviceConfigs, p.context.EnvironmentLookup, p.context.ResourceLookup, file, composeBytes, &options)
+		if err != nil {
+			return nil, err
+		}
+		for name, config := range configs {
+			serviceConfigs.Add(name, config)
+		}
+	}
+	return options.Provenance, nil
+}
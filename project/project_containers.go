@@ -15,8 +15,8 @@ func (p *Project) Containers(ctx context.Context, filter Filter, services ...str
 	containers := []string{}
 	var lock sync.Mutex
 
-	err := p.forEach(services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
-		wrapper.Do(nil, events.NoEvent, events.NoEvent, func(service Service) error {
+	err := p.forEach(ctx, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
+		wrapper.Do(ctx, nil, events.NoEvent, events.NoEvent, func(service Service) error {
 			serviceContainers, innerErr := service.Containers(ctx)
 			if innerErr != nil {
 				return innerErr
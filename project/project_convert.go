@@ -0,0 +1,36 @@
+package project
+
+import (
+	"fmt"
+
+	"github.com/docker/libcompose/config"
+)
+
+// ConvertToV2 converts the version 1 compose files of the project to version 2.
+// It returns the converted content of each file, in the same order as Files,
+// and what couldn't be translated exactly.
+func (p *Project) ConvertToV2() ([][]byte, []config.ConversionNote, error) {
+	if p.configVersion != "" {
+		return nil, nil, fmt.Errorf("Project %s already uses compose file version %s", p.Name, p.configVersion)
+	}
+
+	converted := [][]byte{}
+	notes := []config.ConversionNote{}
+	for i, composeBytes := range p.context.ComposeBytes {
+		file := ""
+		if i < len(p.Files) {
+			file = p.Files[i]
+		}
+		content, fileNotes, err := config.ConvertToV2(composeBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to convert %s: %v", file, err)
+		}
+		for _, note := range fileNotes {
+			note.File = file
+			notes = append(notes, note)
+		}
+		converted = append(converted, content)
+	}
+
+	return converted, notes, nil
+}
@@ -17,8 +17,8 @@ func (p *Project) Create(ctx context.Context, options options.Create, services .
 	if err := p.initialize(ctx); err != nil {
 		return err
 	}
-	return p.perform(events.ProjectCreateStart, events.ProjectCreateDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
-		wrapper.Do(wrappers, events.ServiceCreateStart, events.ServiceCreate, func(service Service) error {
+	return p.perform(ctx, events.ProjectCreateStart, events.ProjectCreateDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
+		wrapper.Do(ctx, wrappers, events.ServiceCreateStart, events.ServiceCreate, func(service Service) error {
 			return service.Create(ctx, options)
 		})
 	}), nil)
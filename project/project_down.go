@@ -46,8 +46,8 @@ func (p *Project) Down(ctx context.Context, opts options.Down, services ...strin
 		}
 	}
 
-	return p.forEach([]string{}, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
-		wrapper.Do(wrappers, events.NoEvent, events.NoEvent, func(service Service) error {
+	return p.forEach(ctx, []string{}, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
+		wrapper.Do(ctx, wrappers, events.NoEvent, events.NoEvent, func(service Service) error {
 			return service.RemoveImage(ctx, opts.RemoveImages)
 		})
 	}), func(service Service) error {
@@ -8,8 +8,8 @@ import (
 
 // Kill kills the specified services (like docker kill).
 func (p *Project) Kill(ctx context.Context, signal string, services ...string) error {
-	return p.perform(events.ProjectKillStart, events.ProjectKillDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
-		wrapper.Do(nil, events.ServiceKillStart, events.ServiceKill, func(service Service) error {
+	return p.perform(ctx, events.ProjectKillStart, events.ProjectKillDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
+		wrapper.Do(ctx, nil, events.ServiceKillStart, events.ServiceKill, func(service Service) error {
 			return service.Kill(ctx, signal)
 		})
 	}), nil)
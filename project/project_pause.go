@@ -8,8 +8,8 @@ import (
 
 // Pause pauses the specified services containers (like docker pause).
 func (p *Project) Pause(ctx context.Context, services ...string) error {
-	return p.perform(events.ProjectPauseStart, events.ProjectPauseDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
-		wrapper.Do(nil, events.ServicePauseStart, events.ServicePause, func(service Service) error {
+	return p.perform(ctx, events.ProjectPauseStart, events.ProjectPauseDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
+		wrapper.Do(ctx, nil, events.ServicePauseStart, events.ServicePause, func(service Service) error {
 			return service.Pause(ctx)
 		})
 	}), nil)
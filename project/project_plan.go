@@ -0,0 +1,205 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/project/options"
+)
+
+// Operation is a project operation that can be planned.
+type Operation string
+
+// Definitions of the operations that can be planned.
+const (
+	OperationUp     = Operation("up")
+	OperationCreate = Operation("create")
+	OperationDown   = Operation("down")
+	OperationDelete = Operation("rm")
+)
+
+// ActionType is what an action does to a resource.
+type ActionType string
+
+// Definitions of the action types.
+const (
+	ActionPull     = ActionType("pull")
+	ActionBuild    = ActionType("build")
+	ActionCreate   = ActionType("create")
+	ActionRecreate = ActionType("recreate")
+	ActionStart    = ActionType("start")
+	ActionStop     = ActionType("stop")
+	ActionKill     = ActionType("kill")
+	ActionRemove   = ActionType("remove")
+	ActionKeep     = ActionType("keep")
+	// ActionFail is an action the operation would fail at, the service is
+	// then left as it is.
+	ActionFail = ActionType("fail")
+)
+
+// ResourceKind is the kind of resource an action applies to.
+type ResourceKind string
+
+// Definitions of the resource kinds.
+const (
+	KindImage     = ResourceKind("image")
+	KindNetwork   = ResourceKind("network")
+	KindVolume    = ResourceKind("volume")
+	KindContainer = ResourceKind("container")
+)
+
+// Action is an action an operation would take on a resource.
+type Action struct {
+	Type ActionType
+	Kind ResourceKind
+	// Name is the name of the resource.
+	Name string
+	// Service is the service the resource belongs to, if any.
+	Service string
+	// Reason explains why the action is needed.
+	Reason string
+}
+
+func (a Action) String() string {
+	s := fmt.Sprintf("%s %s %s", a.Type, a.Kind, a.Name)
+	if a.Service != "" {
+		s += fmt.Sprintf(" (%s)", a.Service)
+	}
+	if a.Reason != "" {
+		s += ": " + a.Reason
+	}
+	return s
+}
+
+// Plan holds the actions of an operation, in the order they would be taken.
+type Plan []Action
+
+// Planner is implemented by the services, networks and volumes that can tell
+// what an operation would do to them without acting. The options are those
+// of the operation: options.Up, options.Create, options.Down or
+// options.Delete.
+type Planner interface {
+	Plan(ctx context.Context, operation Operation, opts interface{}) ([]Action, error)
+}
+
+// OrphansPlanner is implemented by the runtime projects that can tell what
+// RemoveOrphans would do without acting.
+type OrphansPlanner interface {
+	PlanOrphans(ctx context.Context, projectName string, serviceConfigs *config.ServiceConfigs) ([]Action, error)
+}
+
+// Plan returns what the operation would do to the specified services, or to
+// all of them if none is specified, without acting. For up and create, the
+// networks and volumes are planned first, then the services in dependency
+// order. For down, the containers and images of the services are planned
+// first, then the orphans, the networks and, if asked, the volumes. The
+// images removed by down are only planned for the specified services. The
+// networks and volumes that don't implement Planner, and the orphans if the
+// runtime doesn't implement OrphansPlanner, are left out. The services must
+// implement Planner.
+func (p *Project) Plan(ctx context.Context, operation Operation, opts interface{}, services ...string) (Plan, error) {
+	if err := validatePlanOptions(operation, opts); err != nil {
+		return nil, err
+	}
+
+	var plan Plan
+	planResources := func(resources interface{}) error {
+		planner, ok := resources.(Planner)
+		if !ok {
+			return nil
+		}
+		actions, err := planner.Plan(ctx, operation, opts)
+		if err != nil {
+			return err
+		}
+		plan = append(plan, actions...)
+		return nil
+	}
+
+	ordered, err := p.dependencyOrder()
+	if err != nil {
+		return nil, err
+	}
+	selected := map[string]bool{}
+	for _, name := range services {
+		if !p.ServiceConfigs.Has(name) {
+			return nil, errors.New("No such service: " + name)
+		}
+		selected[name] = true
+	}
+
+	if operation == OperationUp || operation == OperationCreate {
+		for _, resources := range []interface{}{p.networks, p.volumes} {
+			if err := planResources(resources); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, service := range ordered {
+		if len(selected) > 0 && !selected[service.Name()] {
+			continue
+		}
+		planner, ok := service.(Planner)
+		if !ok {
+			return nil, fmt.Errorf("Service %s can't be planned", service.Name())
+		}
+		actions, err := planner.Plan(ctx, operation, opts)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to plan service %s: %v", service.Name(), err)
+		}
+		plan = append(plan, actions...)
+	}
+
+	if operation == OperationDown {
+		down := opts.(options.Down)
+		if planner, ok := p.runtime.(OrphansPlanner); ok && down.RemoveOrphans {
+			actions, err := planner.PlanOrphans(ctx, p.Name, p.ServiceConfigs)
+			if err != nil {
+				return nil, err
+			}
+			plan = append(plan, actions...)
+		}
+		if err := planResources(p.networks); err != nil {
+			return nil, err
+		}
+		if down.RemoveVolume {
+			if err := planResources(p.volumes); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return plan, nil
+}
+
+func validatePlanOptions(operation Operation, opts interface{}) error {
+	var ok bool
+	switch operation {
+	case OperationUp:
+		var up options.Up
+		if up, ok = opts.(options.Up); ok && up.NoRecreate && up.ForceRecreate {
+			return fmt.Errorf("no-recreate and force-recreate cannot be combined")
+		}
+	case OperationCreate:
+		var create options.Create
+		if create, ok = opts.(options.Create); ok && create.NoRecreate && create.ForceRecreate {
+			return fmt.Errorf("no-recreate and force-recreate cannot be combined")
+		}
+	case OperationDown:
+		var down options.Down
+		if down, ok = opts.(options.Down); ok && !down.RemoveImages.Valid() {
+			return fmt.Errorf("--rmi flag must be local, all or empty")
+		}
+	case OperationDelete:
+		_, ok = opts.(options.Delete)
+	default:
+		return fmt.Errorf("Operation %s can't be planned", operation)
+	}
+	if !ok {
+		return fmt.Errorf("Invalid options %T for operation %s", opts, operation)
+	}
+	return nil
+}
@@ -8,8 +8,8 @@ import (
 
 // Pull pulls the specified services (like docker pull).
 func (p *Project) Pull(ctx context.Context, services ...string) error {
-	return p.forEach(services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
-		wrapper.Do(nil, events.ServicePullStart, events.ServicePull, func(service Service) error {
+	return p.forEach(ctx, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
+		wrapper.Do(ctx, nil, events.ServicePullStart, events.ServicePull, func(service Service) error {
 			return service.Pull(ctx)
 		})
 	}), nil)
@@ -0,0 +1,102 @@
+package project
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/libcompose/utils"
+)
+
+// CommandRenderer is implemented by the services, networks and volumes that
+// can render the commands equivalent to creating them.
+type CommandRenderer interface {
+	RenderCommands(ctx context.Context) ([][]string, error)
+}
+
+// RenderCommands returns the commands equivalent to creating the project:
+// those of the networks, then of the volumes, then of the services, in
+// dependency order. The networks and volumes that don't implement
+// CommandRenderer are left out, the services must implement it.
+func (p *Project) RenderCommands(ctx context.Context) ([][]string, error) {
+	var commands [][]string
+	for _, resources := range []interface{}{p.networks, p.volumes} {
+		renderer, ok := resources.(CommandRenderer)
+		if !ok {
+			continue
+		}
+		resourceCommands, err := renderer.RenderCommands(ctx)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, resourceCommands...)
+	}
+
+	services, err := p.dependencyOrder()
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		renderer, ok := service.(CommandRenderer)
+		if !ok {
+			return nil, fmt.Errorf("Service %s can't be rendered as commands", service.Name())
+		}
+		serviceCommands, err := renderer.RenderCommands(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to render service %s: %v", service.Name(), err)
+		}
+		commands = append(commands, serviceCommands...)
+	}
+	return commands, nil
+}
+
+// dependencyOrder returns the services of the project, each one after the
+// services it depends on. Cycles through optional dependencies are ignored.
+func (p *Project) dependencyOrder() ([]Service, error) {
+	names := p.ServiceConfigs.Keys()
+	sort.Strings(names)
+
+	services := map[string]Service{}
+	for _, name := range names {
+		service, err := p.CreateService(name)
+		if err != nil {
+			return nil, err
+		}
+		services[name] = service
+	}
+
+	var ordered []Service
+	visited := map[string]bool{}
+	var visit func(name string, history []string) error
+	visit = func(name string, history []string) error {
+		if visited[name] {
+			return nil
+		}
+		history = append(history, name)
+		for _, dep := range services[name].DependentServices() {
+			if _, ok := services[dep.Target]; !ok {
+				continue
+			}
+			if utils.Contains(history, dep.Target) {
+				if dep.Optional {
+					continue
+				}
+				return fmt.Errorf("Cycle detected in path %s", strings.Join(append(history, dep.Target), "->"))
+			}
+			if err := visit(dep.Target, history); err != nil {
+				return err
+			}
+		}
+		visited[name] = true
+		ordered = append(ordered, services[name])
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
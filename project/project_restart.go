@@ -8,8 +8,8 @@ import (
 
 // Restart restarts the specified services (like docker restart).
 func (p *Project) Restart(ctx context.Context, timeout int, services ...string) error {
-	return p.perform(events.ProjectRestartStart, events.ProjectRestartDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
-		wrapper.Do(wrappers, events.ServiceRestartStart, events.ServiceRestart, func(service Service) error {
+	return p.perform(ctx, events.ProjectRestartStart, events.ProjectRestartDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
+		wrapper.Do(ctx, wrappers, events.ServiceRestartStart, events.ServiceRestart, func(service Service) error {
 			return service.Restart(ctx, timeout)
 		})
 	}), nil)
@@ -8,8 +8,8 @@ import (
 
 // Start starts the specified services (like docker start).
 func (p *Project) Start(ctx context.Context, services ...string) error {
-	return p.perform(events.ProjectStartStart, events.ProjectStartDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
-		wrapper.Do(wrappers, events.ServiceStartStart, events.ServiceStart, func(service Service) error {
+	return p.perform(ctx, events.ProjectStartStart, events.ProjectStartDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
+		wrapper.Do(ctx, wrappers, events.ServiceStartStart, events.ServiceStart, func(service Service) error {
 			return service.Start(ctx)
 		})
 	}), nil)
@@ -6,10 +6,11 @@ import (
 	"github.com/docker/libcompose/project/events"
 )
 
-// Stop stops the specified services (like docker stop).
+// Stop stops the specified services (like docker stop), in reverse dependency
+// order: a service is stopped once the services that depend on it are.
 func (p *Project) Stop(ctx context.Context, timeout int, services ...string) error {
-	return p.perform(events.ProjectStopStart, events.ProjectStopDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
-		wrapper.Do(nil, events.ServiceStopStart, events.ServiceStop, func(service Service) error {
+	return p.perform(ctx, events.ProjectStopStart, events.ProjectStopDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
+		wrapper.DoReverse(ctx, wrappers, events.ServiceStopStart, events.ServiceStop, func(service Service) error {
 			return service.Stop(ctx, timeout)
 		})
 	}), nil)
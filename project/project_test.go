@@ -3,13 +3,19 @@ package project
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"golang.org/x/net/context"
 
 	"github.com/docker/libcompose/config"
+	"github.com/docker/libcompose/project/events"
 	"github.com/docker/libcompose/project/options"
+	"github.com/docker/libcompose/utils"
 	"github.com/docker/libcompose/yaml"
 	"github.com/stretchr/testify/assert"
 )
@@ -48,6 +54,10 @@ func (t *TestService) DependentServices() []ServiceRelationship {
 	return nil
 }
 
+func (t *TestService) Plan(ctx context.Context, operation Operation, opts interface{}) ([]Action, error) {
+	return []Action{{Type: ActionCreate, Kind: KindContainer, Name: t.name + "_1", Service: t.name, Reason: string(operation)}}, nil
+}
+
 func (t *TestServiceFactory) Create(project *Project, name string, serviceConfig *config.ServiceConfig) (Service, error) {
 	return &TestService{
 		factory: t,
@@ -149,6 +159,71 @@ func TestParseWithDefaultEnvironmentLookup(t *testing.T) {
 	}
 }
 
+func TestParseWithValues(t *testing.T) {
+	p := NewProject(&Context{
+		ComposeBytes: [][]byte{
+			[]byte("web:\n  image: foo:{{ .tag }}"),
+		},
+		Values: map[string]interface{}{"tag": "1.0"},
+	}, nil, nil)
+
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	web, _ := p.ServiceConfigs.Get("web")
+	if web.Image != "foo:1.0" {
+		t.Fatalf("Compose file was not rendered, got image %s", web.Image)
+	}
+}
+
+func TestParseWithOverlays(t *testing.T) {
+	p := NewProject(&Context{
+		ComposeBytes: [][]byte{
+			[]byte("version: '2'\nservices:\n  web:\n    image: foo\n    ports: [\"80:80\"]\n  debug:\n    image: bar\n"),
+		},
+		OverlayBytes: [][]byte{
+			[]byte("- {op: remove, path: /services/web/ports}\n"),
+			[]byte("services:\n  debug: null\n  web:\n    image: foo:1.0\n"),
+		},
+	}, nil, nil)
+
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if p.ServiceConfigs.Has("debug") {
+		t.Fatal("Overlay failed to remove service debug")
+	}
+	web, _ := p.ServiceConfigs.Get("web")
+	if web.Image != "foo:1.0" || len(web.Ports) != 0 {
+		t.Fatalf("Overlays were not applied to web: %v", web)
+	}
+	if web.Networks == nil || len(web.Networks.Networks) != 1 || web.Networks.Networks[0].Name != "default" {
+		t.Fatalf("Default network should be added after the overlays: %v", web.Networks)
+	}
+}
+
+func TestProvenance(t *testing.T) {
+	p := NewProject(&Context{
+		ComposeFiles: []string{"docker-compose.yml", "docker-compose.override.yml"},
+		ComposeBytes: [][]byte{
+			[]byte("web:\n  image: foo\n"),
+			[]byte("web:\n  image: bar\n"),
+		},
+	}, nil, nil)
+
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	provenance, err := p.Provenance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	explanation, ok := provenance.Get("web", "image")
+	if !ok || explanation.Value != "bar" || explanation.Origin.File != "docker-compose.override.yml" || explanation.Origin.Line != 2 {
+		t.Fatalf("Unexpected provenance of web.image: %v", explanation)
+	}
+}
+
 type TestEnvironmentLookup struct {
 }
 
@@ -156,6 +231,39 @@ func (t *TestEnvironmentLookup) Lookup(key string, config *config.ServiceConfig)
 	return []string{fmt.Sprintf("%s=X", key)}
 }
 
+func TestLoadBuilder(t *testing.T) {
+	builder := config.NewBuilder()
+	builder.Service("web").Image("nginx").Volume("data:/data").Network("front")
+	builder.Volume("data")
+	builder.Network("front")
+
+	p := NewProject(&Context{}, nil, nil)
+	p.Name = "app"
+	if err := p.LoadBuilder(builder); err != nil {
+		t.Fatal(err)
+	}
+	web, ok := p.ServiceConfigs.Get("web")
+	if !ok {
+		t.Fatal("Service web was not added")
+	}
+	if web.Networks.Networks[0].RealName != "app_front" || web.Volumes.Volumes[0].Source != "app_data" {
+		t.Fatalf("Networks and volumes of web were not resolved: %v, %v", web.Networks.Networks[0], web.Volumes.Volumes[0])
+	}
+
+	output, err := p.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(output, "version: \"2.0\"\n") {
+		t.Fatalf("Unexpected config output: %s", output)
+	}
+
+	builder.Service("db").Image("postgres").Network("back")
+	if err := NewProject(&Context{}, nil, nil).LoadBuilder(builder); err == nil {
+		t.Fatal("Expected an error for the undefined network back")
+	}
+}
+
 func TestEnvironmentResolve(t *testing.T) {
 	factory := &TestServiceFactory{
 		Counts: map[string]int{},
@@ -247,3 +355,412 @@ func TestParseWithMultipleComposeFiles(t *testing.T) {
 	assert.Equal(t, yaml.MemStringorInt(41943040), multipleConfig.MemLimit)
 	assert.Equal(t, yaml.MemStringorInt(40000000), multipleConfig.MemSwapLimit)
 }
+
+func TestConfigMasksSecrets(t *testing.T) {
+	for _, showSecrets := range []bool{false, true} {
+		p := NewProject(&Context{
+			ComposeFiles: []string{"docker-compose.yml"},
+			ComposeBytes: [][]byte{[]byte("web:\n  image: nginx\n  environment:\n    API_TOKEN: abcdef123456\n    LEVEL: info\n")},
+			ShowSecrets:  showSecrets,
+		}, nil, nil)
+		if err := p.Parse(); err != nil {
+			t.Fatal(err)
+		}
+
+		output, err := p.Config()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(output, "abcdef123456") != showSecrets || !strings.Contains(output, "LEVEL=info") {
+			t.Fatalf("Unexpected config output with ShowSecrets %v: %s", showSecrets, output)
+		}
+
+		listener := make(chan events.Event, 1)
+		p.AddListener(listener)
+		p.Notify(events.ContainerCreated, "web", map[string]string{"env": "API_TOKEN=abcdef123456"})
+		event := <-listener
+		if strings.Contains(event.Data["env"], "abcdef123456") != showSecrets {
+			t.Fatalf("Unexpected event data with ShowSecrets %v: %v", showSecrets, event.Data)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	p := NewProject(&Context{
+		ServiceFactory: &TestServiceFactory{
+			Counts: map[string]int{},
+		},
+	}, nil, nil)
+	p.ServiceConfigs = config.NewServiceConfigs()
+	p.ServiceConfigs.Add("foo", &config.ServiceConfig{})
+	p.ServiceConfigs.Add("bar", &config.ServiceConfig{})
+
+	plan, err := p.Plan(context.Background(), OperationUp, options.Up{})
+	assert.Nil(t, err)
+	assert.Equal(t, Plan{
+		{Type: ActionCreate, Kind: KindContainer, Name: "bar_1", Service: "bar", Reason: "up"},
+		{Type: ActionCreate, Kind: KindContainer, Name: "foo_1", Service: "foo", Reason: "up"},
+	}, plan)
+	assert.Equal(t, "create container foo_1 (foo): up", plan[1].String())
+
+	plan, err = p.Plan(context.Background(), OperationDelete, options.Delete{}, "foo")
+	assert.Nil(t, err)
+	assert.Len(t, plan, 1)
+	assert.Equal(t, "foo", plan[0].Service)
+
+	_, err = p.Plan(context.Background(), OperationDown, options.Down{}, "baz")
+	assert.EqualError(t, err, "No such service: baz")
+
+	_, err = p.Plan(context.Background(), OperationDown, options.Up{})
+	assert.EqualError(t, err, "Invalid options options.Up for operation down")
+
+	_, err = p.Plan(context.Background(), OperationCreate, options.Create{NoRecreate: true, ForceRecreate: true})
+	assert.EqualError(t, err, "no-recreate and force-recreate cannot be combined")
+}
+
+type slowService struct {
+	TestService
+	running    *int32
+	maxRunning *int32
+	deps       []ServiceRelationship
+	finished   map[string]bool
+	stopped    *[]string
+	mu         *sync.Mutex
+}
+
+func (s *slowService) DependentServices() []ServiceRelationship {
+	return s.deps
+}
+
+func (s *slowService) Create(ctx context.Context, options options.Create) error {
+	s.mu.Lock()
+	for _, dep := range s.deps {
+		if !s.finished[dep.Target] {
+			s.mu.Unlock()
+			return fmt.Errorf("%s created before %s", s.name, dep.Target)
+		}
+	}
+	s.mu.Unlock()
+
+	current := atomic.AddInt32(s.running, 1)
+	for {
+		max := atomic.LoadInt32(s.maxRunning)
+		if current <= max || atomic.CompareAndSwapInt32(s.maxRunning, max, current) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	atomic.AddInt32(s.running, -1)
+
+	s.mu.Lock()
+	s.finished[s.name] = true
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *slowService) Stop(ctx context.Context, timeout int) error {
+	time.Sleep(10 * time.Millisecond)
+	s.mu.Lock()
+	*s.stopped = append(*s.stopped, s.name)
+	s.mu.Unlock()
+	return nil
+}
+
+type slowServiceFactory struct {
+	running, maxRunning int32
+	finished            map[string]bool
+	stopped             []string
+	mu                  sync.Mutex
+}
+
+func (f *slowServiceFactory) Create(project *Project, name string, serviceConfig *config.ServiceConfig) (Service, error) {
+	var deps []ServiceRelationship
+	for _, link := range serviceConfig.Links {
+		deps = append(deps, NewServiceRelationship(link, RelTypeLink))
+	}
+	return &slowService{
+		TestService: TestService{name: name, config: serviceConfig},
+		running:     &f.running,
+		maxRunning:  &f.maxRunning,
+		deps:        deps,
+		finished:    f.finished,
+		stopped:     &f.stopped,
+		mu:          &f.mu,
+	}, nil
+}
+
+func TestParallelism(t *testing.T) {
+	factory := &slowServiceFactory{finished: map[string]bool{}}
+	p := NewProject(&Context{
+		ServiceFactory: factory,
+	}, nil, nil)
+	// Set once the project is created
+	p.context.Parallelism = 2
+	p.ServiceConfigs = config.NewServiceConfigs()
+	p.ServiceConfigs.Add("db", &config.ServiceConfig{})
+	for _, name := range []string{"web1", "web2", "web3", "web4"} {
+		p.ServiceConfigs.Add(name, &config.ServiceConfig{Links: []string{"db"}})
+	}
+
+	assert.Nil(t, p.Create(context.Background(), options.Create{}))
+	assert.Len(t, factory.finished, 5)
+	assert.Equal(t, int32(2), factory.maxRunning)
+}
+
+func TestStopOrder(t *testing.T) {
+	factory := &slowServiceFactory{finished: map[string]bool{}}
+	p := NewProject(&Context{
+		ServiceFactory: factory,
+	}, nil, nil)
+	p.ServiceConfigs = config.NewServiceConfigs()
+	p.ServiceConfigs.Add("db", &config.ServiceConfig{})
+	p.ServiceConfigs.Add("web1", &config.ServiceConfig{Links: []string{"db"}})
+	p.ServiceConfigs.Add("web2", &config.ServiceConfig{Links: []string{"db"}})
+	p.ServiceConfigs.Add("lb", &config.ServiceConfig{Links: []string{"web1", "web2"}})
+
+	assert.Nil(t, p.Stop(context.Background(), 0))
+	order := map[string]int{}
+	for i, name := range factory.stopped {
+		order[name] = i
+	}
+	assert.Len(t, order, 4)
+	assert.Equal(t, 0, order["lb"])
+	assert.Equal(t, 3, order["db"])
+
+	// The services that depend on the stopped ones are left alone
+	factory.stopped = nil
+	assert.Nil(t, p.Stop(context.Background(), 0, "db", "lb"))
+	assert.Equal(t, []string{"lb", "db"}, factory.stopped)
+}
+
+type failingService struct {
+	TestService
+	deps []ServiceRelationship
+}
+
+func (s *failingService) DependentServices() []ServiceRelationship {
+	return s.deps
+}
+
+func (s *failingService) Create(ctx context.Context, options options.Create) error {
+	switch s.config.Image {
+	case "fail":
+		return fmt.Errorf("No such image")
+	case "block":
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (s *failingService) Wait(ctx context.Context) error {
+	switch s.config.Image {
+	case "unhealthy":
+		return fmt.Errorf("Container %s_1 is unhealthy", s.name)
+	case "slow":
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+type failingServiceFactory struct{}
+
+func (failingServiceFactory) Create(project *Project, name string, serviceConfig *config.ServiceConfig) (Service, error) {
+	var deps []ServiceRelationship
+	for _, link := range serviceConfig.Links {
+		deps = append(deps, NewServiceRelationship(link, RelTypeLink))
+	}
+	return &failingService{
+		TestService: TestService{name: name, config: serviceConfig},
+		deps:        deps,
+	}, nil
+}
+
+func TestOperationErrors(t *testing.T) {
+	p := NewProject(&Context{
+		ServiceFactory: failingServiceFactory{},
+	}, nil, nil)
+	p.ServiceConfigs = config.NewServiceConfigs()
+	p.ServiceConfigs.Add("db", &config.ServiceConfig{Image: "fail"})
+	p.ServiceConfigs.Add("cache", &config.ServiceConfig{Image: "fail"})
+	p.ServiceConfigs.Add("app", &config.ServiceConfig{Links: []string{"db"}})
+	p.ServiceConfigs.Add("web", &config.ServiceConfig{Links: []string{"app"}})
+	p.ServiceConfigs.Add("static", &config.ServiceConfig{})
+
+	err := p.Create(context.Background(), options.Create{})
+	assert.Equal(t, utils.MultiError{
+		"cache": fmt.Errorf("No such image"),
+		"db":    fmt.Errorf("No such image"),
+	}, err)
+	assert.Equal(t, "cache: No such image\ndb: No such image", err.Error())
+
+	var outcomes []string
+	for _, outcome := range p.Summary() {
+		outcomes = append(outcomes, outcome.String())
+	}
+	assert.Equal(t, []string{
+		"app: skipped: Dependency db did not complete",
+		"cache: failed: No such image",
+		"db: failed: No such image",
+		"static: done",
+		"web: skipped: Dependency app did not complete",
+	}, outcomes)
+}
+
+func TestOperationCanceled(t *testing.T) {
+	p := NewProject(&Context{
+		ServiceFactory: failingServiceFactory{},
+	}, nil, nil)
+	p.ServiceConfigs = config.NewServiceConfigs()
+	p.ServiceConfigs.Add("db", &config.ServiceConfig{Image: "block"})
+	p.ServiceConfigs.Add("web", &config.ServiceConfig{Links: []string{"db"}})
+	p.ServiceConfigs.Add("static", &config.ServiceConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	err := p.Create(ctx, options.Create{})
+	canceled, ok := err.(*CanceledError)
+	if !ok {
+		t.Fatalf("Expected a CanceledError, got %#v", err)
+	}
+	assert.Equal(t, context.Canceled, canceled.Err)
+	assert.Equal(t, []string{"static"}, canceled.Completed)
+	assert.Equal(t, "Operation canceled: context canceled (completed: static)", err.Error())
+
+	var outcomes []string
+	for _, outcome := range p.Summary() {
+		outcomes = append(outcomes, string(outcome.Outcome))
+	}
+	assert.Equal(t, []string{"canceled", "done", "canceled"}, outcomes)
+}
+
+func TestUpWait(t *testing.T) {
+	p := NewProject(&Context{
+		ServiceFactory: failingServiceFactory{},
+	}, nil, nil)
+	p.ServiceConfigs = config.NewServiceConfigs()
+	p.ServiceConfigs.Add("db", &config.ServiceConfig{Image: "slow"})
+	p.ServiceConfigs.Add("web", &config.ServiceConfig{})
+
+	err := p.Up(context.Background(), options.Up{Wait: true, WaitTimeout: 50 * time.Millisecond})
+	assert.EqualError(t, err, "Timed out after 50ms waiting for the services, ready: web")
+
+	assert.Nil(t, p.Up(context.Background(), options.Up{Wait: true}, "web"))
+
+	p.ServiceConfigs.Add("api", &config.ServiceConfig{Image: "unhealthy"})
+	err = p.Up(context.Background(), options.Up{Wait: true}, "api", "web")
+	assert.EqualError(t, err, "api: Container api_1 is unhealthy")
+}
+
+type attachContainer struct {
+	id string
+}
+
+func (c attachContainer) ID() string   { return c.id }
+func (c attachContainer) Name() string { return c.id }
+func (c attachContainer) Port(ctx context.Context, port string) (string, error) {
+	return "", nil
+}
+func (c attachContainer) IsRunning(ctx context.Context) bool { return true }
+
+type attachServiceFactory struct {
+	events   chan events.ContainerEvent
+	lock     sync.Mutex
+	attached []string
+}
+
+func (f *attachServiceFactory) Create(project *Project, name string, serviceConfig *config.ServiceConfig) (Service, error) {
+	return &attachService{
+		TestService: TestService{name: name, config: serviceConfig},
+		factory:     f,
+	}, nil
+}
+
+func (f *attachServiceFactory) attachedIDs() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	ids := append([]string{}, f.attached...)
+	sort.Strings(ids)
+	return ids
+}
+
+type attachService struct {
+	TestService
+	factory *attachServiceFactory
+}
+
+func (s *attachService) Containers(ctx context.Context) ([]Container, error) {
+	return []Container{attachContainer{id: s.name + "_1"}}, nil
+}
+
+func (s *attachService) LogContainer(ctx context.Context, id string, follow bool) error {
+	s.factory.lock.Lock()
+	s.factory.attached = append(s.factory.attached, id)
+	s.factory.lock.Unlock()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (s *attachService) Events(ctx context.Context, evts chan events.ContainerEvent) error {
+	if s.name != "web" {
+		// A single service forwards the events to keep them in order
+		return nil
+	}
+	for {
+		select {
+		case event := <-s.factory.events:
+			evts <- event
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *attachService) Stop(ctx context.Context, timeout int) error {
+	s.factory.events <- events.ContainerEvent{
+		Service:    s.name,
+		Event:      "die",
+		ID:         s.name + "_2",
+		Attributes: map[string]string{"exitCode": "143"},
+	}
+	return nil
+}
+
+func newAttachProject() (*Project, *attachServiceFactory) {
+	factory := &attachServiceFactory{events: make(chan events.ContainerEvent, 10)}
+	p := NewProject(&Context{
+		ServiceFactory: factory,
+	}, nil, nil)
+	p.ServiceConfigs = config.NewServiceConfigs()
+	p.ServiceConfigs.Add("db", &config.ServiceConfig{})
+	p.ServiceConfigs.Add("web", &config.ServiceConfig{})
+	return p, factory
+}
+
+func TestAttach(t *testing.T) {
+	p, factory := newAttachProject()
+	_, err := p.Attach(context.Background(), options.Attach{ExitCodeFrom: "web"}, "db")
+	assert.EqualError(t, err, "Service web isn't among the attached services")
+
+	// web_2 replaces web_1, then db_1 exits
+	factory.events <- events.ContainerEvent{Service: "web", Event: "start", ID: "web_2"}
+	factory.events <- events.ContainerEvent{Service: "db", Event: "die", ID: "db_1", Attributes: map[string]string{"exitCode": "3"}}
+	code, err := p.Attach(context.Background(), options.Attach{AbortOnContainerExit: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, code)
+	// The output of web_2 may be followed after Attach returned
+	for i := 0; i < 100 && len(factory.attachedIDs()) < 3; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, []string{"db_1", "web_1", "web_2"}, factory.attachedIDs())
+
+	p, factory = newAttachProject()
+	factory.events <- events.ContainerEvent{Service: "db", Event: "die", ID: "db_1", Attributes: map[string]string{"exitCode": "3"}}
+	code, err = p.Attach(context.Background(), options.Attach{ExitCodeFrom: "web"})
+	assert.Nil(t, err)
+	assert.Equal(t, 143, code)
+}
@@ -1,6 +1,11 @@
 package project
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"golang.org/x/net/context"
 
 	"github.com/docker/libcompose/project/events"
@@ -12,11 +17,58 @@ func (p *Project) Up(ctx context.Context, options options.Up, services ...string
 	if err := p.initialize(ctx); err != nil {
 		return err
 	}
-	return p.perform(events.ProjectUpStart, events.ProjectUpDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
-		wrapper.Do(wrappers, events.ServiceUpStart, events.ServiceUp, func(service Service) error {
+	err := p.perform(ctx, events.ProjectUpStart, events.ProjectUpDone, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
+		wrapper.Do(ctx, wrappers, events.ServiceUpStart, events.ServiceUp, func(service Service) error {
 			return service.Up(ctx, options)
 		})
 	}), func(service Service) error {
 		return service.Create(ctx, options.Create)
 	})
+	if err != nil || !options.Wait {
+		return err
+	}
+	return p.wait(ctx, options.WaitTimeout, services)
+}
+
+// Waiter is implemented by the services that can wait for their containers
+// to be ready.
+type Waiter interface {
+	// Wait returns once the containers are running and, if they have a
+	// healthcheck, healthy. It fails if one of them exits or is unhealthy.
+	Wait(ctx context.Context) error
+}
+
+// wait waits for the services to be ready, for timeout at most if positive.
+func (p *Project) wait(ctx context.Context, timeout time.Duration, services []string) error {
+	waitCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := p.forEach(waitCtx, services, wrapperAction(func(wrapper *serviceWrapper, wrappers map[string]*serviceWrapper) {
+		wrapper.Do(waitCtx, nil, events.NoEvent, events.NoEvent, func(service Service) error {
+			waiter, ok := service.(Waiter)
+			if !ok {
+				return fmt.Errorf("Service %s can't be waited for", service.Name())
+			}
+			return waiter.Wait(waitCtx)
+		})
+	}), nil)
+	if canceled, ok := err.(*CanceledError); ok && ctx.Err() == nil {
+		// Only the wait timed out
+		message := fmt.Sprintf("Timed out after %s waiting for the services, ready: %s", timeout, readyList(canceled.Completed))
+		if len(canceled.Errors) > 0 {
+			message += "\n" + canceled.Errors.Error()
+		}
+		return errors.New(message)
+	}
+	return err
+}
+
+func readyList(services []string) string {
+	if len(services) == 0 {
+		return "none"
+	}
+	return strings.Join(services, ", ")
 }
@@ -3,6 +3,8 @@ package project
 import (
 	"sync"
 
+	"golang.org/x/net/context"
+
 	"github.com/docker/libcompose/project/events"
 	log "github.com/sirupsen/logrus"
 )
@@ -15,7 +17,10 @@ type serviceWrapper struct {
 	err     error
 	project *Project
 	noWait  bool
-	ignored map[string]bool
+	// ignored is guarded by ignoredLock, as the services it depends on read
+	// it when going in reverse dependency order.
+	ignoredLock sync.Mutex
+	ignored     map[string]bool
 }
 
 func newServiceWrapper(name string, p *Project) (*serviceWrapper, error) {
@@ -30,9 +35,17 @@ func newServiceWrapper(name string, p *Project) (*serviceWrapper, error) {
 }
 
 func (s *serviceWrapper) IgnoreDep(name string) {
+	s.ignoredLock.Lock()
+	defer s.ignoredLock.Unlock()
 	s.ignored[name] = true
 }
 
+func (s *serviceWrapper) ignoresDep(name string) bool {
+	s.ignoredLock.Lock()
+	defer s.ignoredLock.Unlock()
+	return s.ignored[name]
+}
+
 func (s *serviceWrapper) Reset() error {
 	if s.state != StateExecuted {
 		service, err := s.project.CreateService(s.name)
@@ -59,22 +72,31 @@ func (s *serviceWrapper) Ignore() {
 	s.project.Notify(events.ServiceUpIgnored, s.service.Name(), nil)
 }
 
-func (s *serviceWrapper) waitForDeps(wrappers map[string]*serviceWrapper) bool {
+func (s *serviceWrapper) waitForDeps(ctx context.Context, wrappers map[string]*serviceWrapper) bool {
 	if s.noWait {
 		return true
 	}
 
 	for _, dep := range s.service.DependentServices() {
-		if s.ignored[dep.Target] {
+		if s.ignoresDep(dep.Target) {
 			continue
 		}
 
 		if wrapper, ok := wrappers[dep.Target]; ok {
-			if wrapper.Wait() == ErrRestart {
+			err := wrapper.Wait()
+			if err == ErrRestart {
 				s.project.Notify(events.ProjectReload, wrapper.service.Name(), nil)
 				s.err = ErrRestart
 				return false
 			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				s.err = ctxErr
+				return false
+			}
+			if err != nil {
+				s.err = &DependencyError{Dependency: dep.Target, Err: err}
+				return false
+			}
 		} else {
 			log.Errorf("Failed to find %s", dep.Target)
 		}
@@ -83,16 +105,71 @@ func (s *serviceWrapper) waitForDeps(wrappers map[string]*serviceWrapper) bool {
 	return true
 }
 
-func (s *serviceWrapper) Do(wrappers map[string]*serviceWrapper, start, done events.EventType, action func(service Service) error) {
+// waitForDependents waits for the services that depend on this one, even
+// through services that aren't selected, for the operations that go in
+// reverse dependency order. Unlike waitForDeps, a dependent that failed
+// doesn't prevent the action.
+func (s *serviceWrapper) waitForDependents(ctx context.Context, wrappers map[string]*serviceWrapper) bool {
+	visited := map[string]bool{s.name: true}
+	var wait func(name string) bool
+	wait = func(name string) bool {
+		for _, wrapper := range wrappers {
+			if visited[wrapper.name] || wrapper.ignoresDep(name) {
+				continue
+			}
+			for _, dep := range wrapper.service.DependentServices() {
+				if dep.Target != name {
+					continue
+				}
+				visited[wrapper.name] = true
+				wrapper.Wait()
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					s.err = ctxErr
+					return false
+				}
+				if !wait(wrapper.name) {
+					return false
+				}
+				break
+			}
+		}
+		return true
+	}
+
+	return wait(s.name)
+}
+
+func (s *serviceWrapper) Do(ctx context.Context, wrappers map[string]*serviceWrapper, start, done events.EventType, action func(service Service) error) {
+	s.do(ctx, func() bool {
+		return wrappers == nil || s.waitForDeps(ctx, wrappers)
+	}, start, done, action)
+}
+
+// DoReverse is like Do, but waits for the services that depend on this one
+// instead of its dependencies.
+func (s *serviceWrapper) DoReverse(ctx context.Context, wrappers map[string]*serviceWrapper, start, done events.EventType, action func(service Service) error) {
+	s.do(ctx, func() bool {
+		return s.waitForDependents(ctx, wrappers)
+	}, start, done, action)
+}
+
+func (s *serviceWrapper) do(ctx context.Context, wait func() bool, start, done events.EventType, action func(service Service) error) {
 	defer s.done.Done()
 
 	if s.state == StateExecuted {
 		return
 	}
 
-	if wrappers != nil && !s.waitForDeps(wrappers) {
+	if !wait() {
+		return
+	}
+
+	// The slot is only taken once the dependencies are done, so that they
+	// can't wait for it. Once the context is canceled, no new action starts.
+	if s.err = s.project.Slots().AcquireContext(ctx); s.err != nil {
 		return
 	}
+	defer s.project.Slots().Release()
 
 	s.state = StateExecuted
 
@@ -2,42 +2,145 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
 
 	"gopkg.in/yaml.v2"
 )
 
-// InParallel holds a pool and a waitgroup to execute tasks in parallel and to be able
-// to wait for completion of all tasks.
+// InParallel holds a waitgroup to execute tasks in parallel and to be able
+// to wait for completion of all tasks, and the errors of the tasks.
 type InParallel struct {
-	wg   sync.WaitGroup
-	pool sync.Pool
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	errors MultiError
+	count  int
+	// Limit is the number of tasks run at once, unlimited if not positive.
+	Limit int
+	once  sync.Once
+	slots Semaphore
 }
 
-// Add runs the specified task in parallel and adds it to the waitGroup.
+// Add runs the specified task in parallel and adds it to the waitGroup. Its
+// error is named after the order it was added in, like "#1".
 func (i *InParallel) Add(task func() error) {
+	i.mu.Lock()
+	i.count++
+	name := fmt.Sprintf("#%d", i.count)
+	i.mu.Unlock()
+	i.AddNamed(name, task)
+}
+
+// AddNamed runs the specified task in parallel and adds it to the waitGroup.
+// Its error is named after name.
+func (i *InParallel) AddNamed(name string, task func() error) {
+	i.once.Do(func() {
+		i.slots = NewSemaphore(i.Limit)
+	})
 	i.wg.Add(1)
 
 	go func() {
 		defer i.wg.Done()
-		err := task()
-		if err != nil {
-			i.pool.Put(err)
+		i.slots.Acquire()
+		defer i.slots.Release()
+		if err := task(); err != nil {
+			i.mu.Lock()
+			defer i.mu.Unlock()
+			if i.errors == nil {
+				i.errors = MultiError{}
+			}
+			i.errors[name] = err
 		}
 	}()
 }
 
-// Wait waits for all tasks to complete and returns the latest error encountered if any.
+// Wait waits for all tasks to complete and returns their errors as a
+// MultiError, or nil if none failed.
 func (i *InParallel) Wait() error {
 	i.wg.Wait()
-	obj := i.pool.Get()
-	if err, ok := obj.(error); ok {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if len(i.errors) == 0 {
+		return nil
+	}
+	return i.errors
+}
+
+// MultiError maps names, like those of services or containers, to the errors
+// of the tasks run for them. The errors may be MultiErrors themselves.
+type MultiError map[string]error
+
+// Error returns a line per error, sorted by name and prefixed with the names
+// of the MultiErrors holding it, like "web: myapp_web_1: No such image".
+func (m MultiError) Error() string {
+	return strings.Join(m.lines(""), "\n")
+}
+
+func (m MultiError) lines(prefix string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		if nested, ok := m[name].(MultiError); ok {
+			lines = append(lines, nested.lines(prefix+name+": ")...)
+			continue
+		}
+		lines = append(lines, prefix+name+": "+m[name].Error())
+	}
+	return lines
+}
+
+// Semaphore limits the number of tasks running at once. The nil semaphore
+// doesn't limit them.
+type Semaphore chan struct{}
+
+// NewSemaphore returns a semaphore letting limit tasks run at once, or nil if
+// limit is not positive.
+func NewSemaphore(limit int) Semaphore {
+	if limit <= 0 {
+		return nil
+	}
+	return make(Semaphore, limit)
+}
+
+// Acquire waits for a task to be allowed to run.
+func (s Semaphore) Acquire() {
+	if s != nil {
+		s <- struct{}{}
+	}
+}
+
+// AcquireContext waits for a task to be allowed to run, unless the context
+// is canceled first. It returns the error of the context in that case.
+func (s Semaphore) AcquireContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
-	return nil
+	if s == nil {
+		return nil
+	}
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release lets another task run.
+func (s Semaphore) Release() {
+	if s != nil {
+		<-s
+	}
 }
 
 // ConvertByJSON converts a struct (src) to another one (target) using json marshalling/unmarshalling.
@@ -92,6 +195,30 @@ func CopyMap(m map[string]string) map[string]string {
 	return r
 }
 
+// SortedPairs returns the entries of a string-to-string map as KEY=VALUE
+// strings, sorted.
+func SortedPairs(m map[string]string) []string {
+	pairs := make([]string, 0, len(m))
+	for key, value := range m {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// ShellQuote quotes an argument for a POSIX shell if needed.
+func ShellQuote(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	for _, r := range arg {
+		if !strings.ContainsRune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=,@%+", r) {
+			return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+		}
+	}
+	return arg
+}
+
 // FilterStringSet accepts a string set `s` (in the form of `map[string]bool`) and a filtering function `f`
 // and returns a string set containing only the strings `x` for which `f(x) == true`
 func FilterStringSet(s map[string]bool, f func(x string) bool) map[string]bool {
@@ -5,7 +5,9 @@ package utils
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 type safeMap struct {
@@ -83,3 +85,49 @@ func TestInParallelError(t *testing.T) {
 		}
 	}
 }
+
+func TestInParallelLimit(t *testing.T) {
+	var running, maxRunning int32
+	tasks := InParallel{Limit: 2}
+	for i := 0; i < 6; i++ {
+		tasks.Add(func() error {
+			current := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}
+	if err := tasks.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if maxRunning > 2 {
+		t.Fatalf("Expected at most 2 tasks running at once, got %d", maxRunning)
+	}
+}
+
+func TestInParallelErrors(t *testing.T) {
+	tasks := InParallel{}
+	tasks.AddNamed("web", func() error {
+		inner := InParallel{}
+		inner.AddNamed("myapp_web_2", func() error { return fmt.Errorf("No such image") })
+		inner.AddNamed("myapp_web_1", func() error { return fmt.Errorf("Port is already allocated") })
+		return inner.Wait()
+	})
+	tasks.AddNamed("db", func() error { return nil })
+	tasks.Add(func() error { return fmt.Errorf("Failed") })
+
+	err := tasks.Wait()
+	if _, ok := err.(MultiError); !ok {
+		t.Fatalf("Expected a MultiError, got %#v", err)
+	}
+	expected := "#1: Failed\nweb: myapp_web_1: Port is already allocated\nweb: myapp_web_2: No such image"
+	if err.Error() != expected {
+		t.Fatalf("Expected %q, got %q", expected, err.Error())
+	}
+}
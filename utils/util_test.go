@@ -211,3 +211,22 @@ func TestMerge(t *testing.T) {
 	}
 	assert.Equal(t, "a:b", fmt.Sprint("a", ":", "b"))
 }
+
+func TestSortedPairs(t *testing.T) {
+	assert.Equal(t, []string{}, SortedPairs(nil))
+	assert.Equal(t, []string{"a=1", "b=", "c=3=4"}, SortedPairs(map[string]string{"c": "3=4", "a": "1", "b": ""}))
+}
+
+func TestShellQuote(t *testing.T) {
+	for arg, expected := range map[string]string{
+		"":                   "''",
+		"nginx:1.13":         "nginx:1.13",
+		"--env=DEBUG=1":      "--env=DEBUG=1",
+		"run app":            "'run app'",
+		"$HOME":              "'$HOME'",
+		"it's":               `'it'\''s'`,
+		"http://localhost/a": "http://localhost/a",
+	} {
+		assert.Equal(t, expected, ShellQuote(arg), arg)
+	}
+}
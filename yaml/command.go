@@ -11,6 +11,12 @@ import (
 // Command represents a docker command, can be a string or an array of strings.
 type Command strslice.StrSlice
 
+// MarshalYAML implements the Marshaller interface, as a list so that the
+// arguments don't need quoting.
+func (s Command) MarshalYAML() (interface{}, error) {
+	return []string(s), nil
+}
+
 // UnmarshalYAML implements the Unmarshaller interface.
 func (s *Command) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var stringType string
@@ -3,6 +3,7 @@ package yaml
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -13,6 +14,11 @@ import (
 // StringorInt represents a string or an integer.
 type StringorInt int64
 
+// MarshalYAML implements the Marshaller interface, as an integer.
+func (s StringorInt) MarshalYAML() (interface{}, error) {
+	return int64(s), nil
+}
+
 // UnmarshalYAML implements the Unmarshaller interface.
 func (s *StringorInt) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var intType int64
@@ -39,6 +45,28 @@ func (s *StringorInt) UnmarshalYAML(unmarshal func(interface{}) error) error {
 // the String supports notations like 10m for then Megabyte of memory
 type MemStringorInt int64
 
+// memUnits are the units of MemStringorInt, largest first.
+var memUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"g", units.GiB},
+	{"m", units.MiB},
+	{"k", units.KiB},
+}
+
+// MarshalYAML implements the Marshaller interface. Sizes that are a
+// multiple of a unit are written with the largest one, like 512m, others as
+// a number of bytes.
+func (s MemStringorInt) MarshalYAML() (interface{}, error) {
+	for _, unit := range memUnits {
+		if s > 0 && int64(s)%unit.size == 0 {
+			return strconv.FormatInt(int64(s)/unit.size, 10) + unit.suffix, nil
+		}
+	}
+	return int64(s), nil
+}
+
 // UnmarshalYAML implements the Unmarshaller interface.
 func (s *MemStringorInt) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var intType int64
@@ -65,6 +93,11 @@ func (s *MemStringorInt) UnmarshalYAML(unmarshal func(interface{}) error) error
 // Using engine-api Strslice and augment it with YAML marshalling stuff. a string or an array of strings.
 type Stringorslice strslice.StrSlice
 
+// MarshalYAML implements the Marshaller interface, always as a list.
+func (s Stringorslice) MarshalYAML() (interface{}, error) {
+	return []string(s), nil
+}
+
 // UnmarshalYAML implements the Unmarshaller interface.
 func (s *Stringorslice) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var stringType string
@@ -89,6 +122,11 @@ func (s *Stringorslice) UnmarshalYAML(unmarshal func(interface{}) error) error {
 // SliceorMap represents a slice or a map of strings.
 type SliceorMap map[string]string
 
+// MarshalYAML implements the Marshaller interface, as a mapping.
+func (s SliceorMap) MarshalYAML() (interface{}, error) {
+	return map[string]string(s), nil
+}
+
 // UnmarshalYAML implements the Unmarshaller interface.
 func (s *SliceorMap) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
@@ -149,6 +187,26 @@ func (s *MaporEqualSlice) UnmarshalYAML(unmarshal func(interface{}) error) error
 	return nil
 }
 
+// MarshalYAML implements the Marshaller interface, as a list sorted by key.
+// The last value of a duplicated key wins, as with ToMap.
+func (s MaporEqualSlice) MarshalYAML() (interface{}, error) {
+	result := []string{}
+	index := map[string]int{}
+	for _, item := range s {
+		key := strings.SplitN(item, "=", 2)[0]
+		if i, ok := index[key]; ok {
+			result[i] = item
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, item)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return strings.SplitN(result[i], "=", 2)[0] < strings.SplitN(result[j], "=", 2)[0]
+	})
+	return result, nil
+}
+
 // ToMap returns the list of string as a map splitting using = the key=value
 func (s *MaporEqualSlice) ToMap() map[string]string {
 	return toMap(*s, "=")
@@ -168,6 +226,12 @@ func (s *MaporColonSlice) UnmarshalYAML(unmarshal func(interface{}) error) error
 	return nil
 }
 
+// MarshalYAML implements the Marshaller interface, as a sorted list since
+// links, the main use, can't be a mapping in compose files.
+func (s MaporColonSlice) MarshalYAML() (interface{}, error) {
+	return sortedStrings(s), nil
+}
+
 // ToMap returns the list of string as a map splitting using = the key=value
 func (s *MaporColonSlice) ToMap() map[string]string {
 	return toMap(*s, ":")
@@ -187,11 +251,24 @@ func (s *MaporSpaceSlice) UnmarshalYAML(unmarshal func(interface{}) error) error
 	return nil
 }
 
+// MarshalYAML implements the Marshaller interface, as a sorted list.
+func (s MaporSpaceSlice) MarshalYAML() (interface{}, error) {
+	return sortedStrings(s), nil
+}
+
 // ToMap returns the list of string as a map splitting using = the key=value
 func (s *MaporSpaceSlice) ToMap() map[string]string {
 	return toMap(*s, " ")
 }
 
+// sortedStrings returns a sorted copy of items, that may have been parsed
+// from a mapping in any order.
+func sortedStrings(items []string) []string {
+	result := append([]string{}, items...)
+	sort.Strings(result)
+	return result
+}
+
 func unmarshalToStringOrSepMapParts(unmarshal func(interface{}) error, key string) ([]string, error) {
 	var sliceType []interface{}
 	if err := unmarshal(&sliceType); err == nil {
@@ -2,7 +2,10 @@ package yaml
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/quick"
 
 	"gopkg.in/yaml.v2"
 
@@ -212,3 +215,129 @@ func TestEqualSliceToMapEqualSign(t *testing.T) {
 	result = slice.ToMap()
 	assert.Equal(t, "bar=baz=buz", result["foo"])
 }
+
+type StructCanonical struct {
+	Command     Command         `yaml:"command,omitempty"`
+	DNS         Stringorslice   `yaml:"dns,omitempty"`
+	Environment MaporEqualSlice `yaml:"environment,omitempty"`
+	Links       MaporColonSlice `yaml:"links,omitempty"`
+	Options     MaporSpaceSlice `yaml:"options,omitempty"`
+	Labels      SliceorMap      `yaml:"labels,omitempty"`
+	CPUShares   StringorInt     `yaml:"cpu_shares,omitempty"`
+	MemLimit    MemStringorInt  `yaml:"mem_limit,omitempty"`
+	ShmSize     MemStringorInt  `yaml:"shm_size,omitempty"`
+}
+
+func TestMarshalCanonical(t *testing.T) {
+	str := `command: bash -c "echo hello"
+dns: 8.8.8.8
+environment: [B=2, A=1, EMPTY=, LOOKUP, A=3]
+links: {db: database, cache: ""}
+options: {rw: "", size: 10}
+labels: [tier=front, app=web]
+cpu_shares: "512"
+mem_limit: 512m
+shm_size: 1000
+`
+	expected := `command:
+- bash
+- -c
+- echo hello
+dns:
+- 8.8.8.8
+environment:
+- A=3
+- B=2
+- EMPTY=
+- LOOKUP
+links:
+- 'cache:'
+- db:database
+options:
+- 'rw '
+- size 10
+labels:
+  app: web
+  tier: front
+cpu_shares: 512
+mem_limit: 512m
+shm_size: 1000
+`
+
+	s := StructCanonical{}
+	assert.Nil(t, yaml.Unmarshal([]byte(str), &s))
+	d, err := yaml.Marshal(&s)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, string(d))
+
+	s2 := StructCanonical{}
+	assert.Nil(t, yaml.Unmarshal(d, &s2))
+	d2, err := yaml.Marshal(&s2)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, string(d2))
+	assert.Equal(t, []string{"A=3", "B=2", "EMPTY=", "LOOKUP"}, []string(s2.Environment))
+}
+
+func TestMarshalMemStringorInt(t *testing.T) {
+	for value, expected := range map[int64]string{
+		0:             "0",
+		-1:            "-1",
+		60:            "60",
+		1000:          "1000",
+		1024:          "1k",
+		1536:          "1536",
+		512 << 20:     "512m",
+		3 << 30:       "3g",
+		(2 << 30) + 1: "2147483649",
+	} {
+		d, err := yaml.Marshal(MemStringorInt(value))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, strings.TrimSpace(string(d)))
+
+		var parsed MemStringorInt
+		assert.Nil(t, yaml.Unmarshal(d, &parsed))
+		assert.Equal(t, MemStringorInt(value), parsed)
+	}
+}
+
+func TestMarshalRoundTripProperty(t *testing.T) {
+	roundTrip := func(environment []string, links []string, mem int64) bool {
+		for i, item := range environment {
+			environment[i] = strings.TrimLeft(item, "=")
+		}
+		for i, item := range links {
+			links[i] = strings.TrimLeft(item, ":")
+		}
+		s := StructCanonical{
+			Environment: MaporEqualSlice(environment),
+			Links:       MaporColonSlice(links),
+			MemLimit:    MemStringorInt(mem),
+		}
+		d, err := yaml.Marshal(&s)
+		if err != nil {
+			return false
+		}
+		s2 := StructCanonical{}
+		if err := yaml.Unmarshal(d, &s2); err != nil {
+			return false
+		}
+		d2, err := yaml.Marshal(&s2)
+		if err != nil {
+			return false
+		}
+		return string(d) == string(d2) &&
+			reflect.DeepEqual(lastValues(s.Environment), lastValues(s2.Environment)) &&
+			strings.Join(sortedStrings(s.Links), "\n") == strings.Join(s2.Links, "\n") &&
+			s.MemLimit == s2.MemLimit
+	}
+	assert.Nil(t, quick.Check(roundTrip, nil))
+}
+
+func lastValues(items []string) map[string]string {
+	result := map[string]string{}
+	for _, item := range items {
+		parts := append(strings.SplitN(item, "=", 2), "<unset>")
+		result[parts[0]] = parts[1]
+	}
+	return result
+}